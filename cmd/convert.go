@@ -0,0 +1,322 @@
+package cmd
+
+import (
+	"csv2json/internal/converter"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	outDir     string
+	jobs       int
+	forceBatch bool
+)
+
+var convertCmd = &cobra.Command{
+	Use:   "convert <file|dir|glob>...",
+	Short: "Convert many CSV files concurrently",
+	Long: `Convert multiple CSV files to JSON in parallel.
+
+Inputs may be files, glob patterns or directories (searched recursively for
+.csv files). Outputs mirror the directory structure below each input's base
+directory. Outputs newer than their input are skipped unless --force is given.
+
+Examples:
+  csv2json convert 'in/*.csv' --out-dir out/
+  csv2json convert in/ --out-dir out/ --jobs 8 --compress gzip`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		options, err := buildOptions(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		tasks, err := planBatch(args, outDir, compressOutput)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(tasks) == 0 {
+			fmt.Fprintln(os.Stderr, "Error: no input files matched")
+			os.Exit(1)
+		}
+
+		results := runBatch(tasks, jobs, func(task batchTask) error {
+			return convertFile(task.input, task.output, optionsForFile(cmd, options, task.input), task.compression)
+		})
+
+		if failed := printBatchSummary(os.Stdout, results); failed > 0 {
+			fmt.Fprintf(os.Stderr, "%d of %d files failed\n", failed, len(results))
+			os.Exit(1)
+		}
+	},
+}
+
+// batchTask is one input file and the output it converts to
+type batchTask struct {
+	input       string
+	output      string
+	compression string
+}
+
+// batchResult records the outcome of a batchTask
+type batchResult struct {
+	task     batchTask
+	status   string // "converted", "skipped" or "failed"
+	err      error
+	duration time.Duration
+	size     int64
+}
+
+// planBatch expands the input arguments and maps each file to its output path
+func planBatch(args []string, outDir, compression string) ([]batchTask, error) {
+	var tasks []batchTask
+	seen := make(map[string]bool)
+
+	for _, arg := range args {
+		base, files, err := expandInput(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			if seen[file] {
+				continue
+			}
+			seen[file] = true
+
+			rel, err := filepath.Rel(base, file)
+			if err != nil || strings.HasPrefix(rel, "..") {
+				rel = filepath.Base(file)
+			}
+			output := filepath.Join(filepath.Dir(file), jsonName(filepath.Base(file), compression))
+			if outDir != "" {
+				output = filepath.Join(outDir, filepath.Dir(rel), jsonName(filepath.Base(rel), compression))
+			}
+
+			tasks = append(tasks, batchTask{input: file, output: output, compression: compression})
+		}
+	}
+	return tasks, checkOutputs(tasks)
+}
+
+// checkOutputs rejects plans where two inputs share an output, such as d.csv
+// and d.csv.gz, or where an output would overwrite one of the inputs
+func checkOutputs(tasks []batchTask) error {
+	inputs := make(map[string]string, len(tasks))
+	for _, task := range tasks {
+		inputs[absPath(task.input)] = task.input
+	}
+
+	outputs := make(map[string]string, len(tasks))
+	for _, task := range tasks {
+		output := absPath(task.output)
+		if input, ok := inputs[output]; ok {
+			return fmt.Errorf("the output of %s would overwrite the input %s", task.input, input)
+		}
+		if other, ok := outputs[output]; ok {
+			return fmt.Errorf("%s and %s would both be converted to %s", other, task.input, task.output)
+		}
+		outputs[output] = task.input
+	}
+	return nil
+}
+
+// absPath returns the absolute form of path, falling back to the cleaned path
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
+
+// expandInput resolves a file, directory or glob to the files it names and the
+// base directory that output paths are made relative to
+func expandInput(arg string) (string, []string, error) {
+	if info, err := os.Stat(arg); err == nil && info.IsDir() {
+		var files []string
+		err := filepath.WalkDir(arg, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && isBatchInput(path) {
+				files = append(files, path)
+			}
+			return nil
+		})
+		return arg, files, err
+	}
+
+	matches, err := filepath.Glob(arg)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid pattern %q: %w", arg, err)
+	}
+
+	var files []string
+	for _, match := range matches {
+		if info, err := os.Stat(match); err == nil && !info.IsDir() {
+			files = append(files, match)
+		}
+	}
+	sort.Strings(files)
+	return globBase(arg), files, nil
+}
+
+// globBase returns the directory part of a pattern before its first wildcard
+func globBase(pattern string) string {
+	idx := strings.IndexAny(pattern, "*?[")
+	if idx < 0 {
+		return filepath.Dir(pattern)
+	}
+	return filepath.Dir(pattern[:idx+1])
+}
+
+// isBatchInput reports whether a file found in a directory should be converted
+func isBatchInput(path string) bool {
+	name := strings.ToLower(path)
+	name = strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ".bz2")
+	return strings.HasSuffix(name, ".csv") || strings.HasSuffix(name, ".xlsx")
+}
+
+// jsonName replaces the input extensions of a file name with .json (or .json.gz)
+func jsonName(name, compression string) string {
+	name = strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ".bz2")
+	name = strings.TrimSuffix(name, filepath.Ext(name)) + ".json"
+	if compression == "gzip" {
+		name += ".gz"
+	}
+	return name
+}
+
+// runBatch runs convert for each task on a bounded pool of workers, skipping
+// tasks whose output is newer than their input unless --force is set
+func runBatch(tasks []batchTask, workers int, convert func(batchTask) error) []batchResult {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	results := make([]batchResult, len(tasks))
+	taskChan := make(chan int)
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range taskChan {
+				results[idx] = runTask(tasks[idx], convert)
+			}
+		}()
+	}
+
+	for i := range tasks {
+		taskChan <- i
+	}
+	close(taskChan)
+	wg.Wait()
+
+	return results
+}
+
+// runTask converts a single file and records its outcome
+func runTask(task batchTask, convert func(batchTask) error) batchResult {
+	result := batchResult{task: task}
+
+	if !forceBatch && upToDate(task.input, task.output) {
+		result.status = "skipped"
+		return result
+	}
+
+	start := time.Now()
+	if err := os.MkdirAll(filepath.Dir(task.output), 0755); err != nil {
+		result.status, result.err = "failed", err
+		return result
+	}
+	if err := convert(task); err != nil {
+		os.Remove(task.output)
+		result.status, result.err = "failed", err
+		return result
+	}
+
+	result.status = "converted"
+	result.duration = time.Since(start)
+	if info, err := os.Stat(task.output); err == nil {
+		result.size = info.Size()
+	}
+	return result
+}
+
+// upToDate reports whether output exists and is at least as new as input
+func upToDate(input, output string) bool {
+	inInfo, err := os.Stat(input)
+	if err != nil {
+		return false
+	}
+	outInfo, err := os.Stat(output)
+	if err != nil {
+		return false
+	}
+	return !outInfo.ModTime().Before(inInfo.ModTime())
+}
+
+// convertFile converts one input file to an output file
+func convertFile(inputPath, outputPath string, options converter.ConversionOptions, compression string) error {
+	file, err := os.Open(inputPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	input, _, err := converter.Decompress(file)
+	if err != nil {
+		return err
+	}
+	return writeOutputFile(outputPath, compression, func(w io.Writer) error {
+		return converter.ConvertCSVToJSONStream(input, w, options)
+	})
+}
+
+// printBatchSummary writes a per-file table and returns the number of failures
+func printBatchSummary(w io.Writer, results []batchResult) int {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "INPUT\tSTATUS\tOUTPUT\tSIZE\tTIME")
+
+	counts := make(map[string]int)
+	for _, result := range results {
+		counts[result.status]++
+		detail := result.task.output
+		if result.err != nil {
+			detail = result.err.Error()
+		}
+
+		size, elapsed := "-", "-"
+		if result.status == "converted" {
+			size = fmt.Sprintf("%d", result.size)
+			elapsed = result.duration.Round(time.Millisecond).String()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", result.task.input, result.status, detail, size, elapsed)
+	}
+	tw.Flush()
+
+	fmt.Fprintf(w, "\n%d converted, %d skipped, %d failed\n", counts["converted"], counts["skipped"], counts["failed"])
+	return counts["failed"]
+}
+
+func init() {
+	convertCmd.Flags().StringVar(&outDir, "out-dir", "", "Directory for JSON outputs (default: next to each input)")
+	convertCmd.Flags().IntVarP(&jobs, "jobs", "j", runtime.NumCPU(), "Number of files converted concurrently")
+	convertCmd.Flags().BoolVar(&forceBatch, "force", false, "Convert even when the output is newer than the input")
+
+	rootCmd.AddCommand(convertCmd)
+}
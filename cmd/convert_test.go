@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPlanBatchRejectsCollidingOutputs(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.csv", "d.csv", "d.csv.gz", "x.json"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("id\n1\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		args    []string
+		outDir  string
+		message string
+	}{
+		{[]string{filepath.Join(dir, "d.csv*")}, filepath.Join(dir, "out"), "would both be converted to"},
+		{[]string{filepath.Join(dir, "x.*")}, "", "would overwrite the input"},
+	}
+	for _, tt := range tests {
+		_, err := planBatch(tt.args, tt.outDir, "")
+		if err == nil || !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("planBatch(%q) error = %v, want it to contain %q", tt.args, err, tt.message)
+		}
+	}
+
+	tasks, err := planBatch([]string{filepath.Join(dir, "a.csv"), filepath.Join(dir, "d.csv")}, filepath.Join(dir, "out"), "")
+	if err != nil {
+		t.Fatalf("planBatch() error = %v", err)
+	}
+	if len(tasks) != 2 || tasks[1].output != filepath.Join(dir, "out", "d.json") {
+		t.Errorf("planBatch() = %+v", tasks)
+	}
+}
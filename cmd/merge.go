@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"csv2json/internal/converter"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	mergeOutput  string
+	mergeAliases []string
+	tagSource    bool
+	sourceField  string
+)
+
+var mergeCmd = &cobra.Command{
+	Use:   "merge <file>...",
+	Short: "Merge CSV files with differing headers into one JSON output",
+	Long: `Concatenate several CSV files into a single JSON array or NDJSON stream.
+
+The output uses the union of all headers; fields missing from a file are null.
+Headers can be renamed with --alias so drifting column names line up.
+
+Examples:
+  csv2json merge jan.csv feb.csv mar.csv -o q1.json
+  csv2json merge exports/*.csv --alias "E-mail=email,Mail=email" --tag-source --format ndjson`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		options, err := buildOptions(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		aliases, err := parseAliases(mergeAliases)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		mergeOptions := converter.MergeOptions{
+			ConversionOptions: options,
+			Aliases:           aliases,
+		}
+		if tagSource {
+			mergeOptions.SourceField = sourceField
+		}
+
+		var inputs []converter.MergeInput
+		for _, path := range args {
+			file, err := os.Open(path)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error opening input file: %v\n", err)
+				os.Exit(1)
+			}
+			defer file.Close()
+
+			input, _, err := converter.Decompress(file)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", path, err)
+				os.Exit(1)
+			}
+			inputs = append(inputs, converter.MergeInput{Name: filepath.Base(path), Reader: input})
+		}
+
+		merge := func(w io.Writer) error {
+			return converter.MergeCSVToJSON(inputs, w, mergeOptions)
+		}
+		if err := writeOutput(cmd, mergeOutput, options.OutputFormat != "ndjson", merge); err != nil {
+			fmt.Fprintf(os.Stderr, "Error merging CSV files: %v\n", err)
+			os.Exit(1)
+		}
+		if mergeOutput != "" && mergeOutput != "-" {
+			fmt.Fprintf(os.Stderr, "Successfully merged %d files to %s\n", len(args), mergeOutput)
+		}
+	},
+}
+
+// parseAliases parses "from=to" pairs, given as repeated flags or comma-separated
+func parseAliases(values []string) (map[string]string, error) {
+	aliases := make(map[string]string)
+	for _, value := range values {
+		for _, pair := range strings.Split(value, ",") {
+			from, to, ok := strings.Cut(pair, "=")
+			if !ok || strings.TrimSpace(from) == "" || strings.TrimSpace(to) == "" {
+				return nil, fmt.Errorf("invalid alias %q, expected from=to", pair)
+			}
+			aliases[strings.TrimSpace(from)] = strings.TrimSpace(to)
+		}
+	}
+	return aliases, nil
+}
+
+func init() {
+	mergeCmd.Flags().StringVarP(&mergeOutput, "output", "o", "", "Output JSON file (default: stdout)")
+	mergeCmd.Flags().StringArrayVar(&mergeAliases, "alias", nil, "Header aliases as from=to pairs, e.g. 'E-mail=email'")
+	mergeCmd.Flags().BoolVar(&tagSource, "tag-source", false, "Add the source file name to every record")
+	mergeCmd.Flags().StringVar(&sourceField, "source-field", "_source", "Field name used by --tag-source")
+
+	rootCmd.AddCommand(mergeCmd)
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"csv2json/internal/converter"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var queryOutput string
+
+var queryCmd = &cobra.Command{
+	Use:   "query <sql>",
+	Short: "Run a SQL SELECT over CSV files",
+	Long: `Run a SELECT statement over one CSV file, or two joined on a column, and
+output the result rows in any of the JSON formats.
+
+Supported: projection with aliases, DISTINCT, WHERE (=, <>, <, <=, >, >=, LIKE,
+IN, BETWEEN, IS NULL, AND, OR, NOT), GROUP BY with COUNT, SUM, AVG, MIN, MAX,
+FIRST and LAST, HAVING, ORDER BY a column, alias or position, LIMIT and OFFSET,
+and [INNER | LEFT] JOIN ... ON a.col = b.col. Tables are named after their file
+(orders.csv is "orders") unless given an alias; "-" reads stdin.
+
+Examples:
+  csv2json query "SELECT department, AVG(salary) FROM data.csv WHERE active GROUP BY department ORDER BY 2 DESC LIMIT 10"
+  csv2json query "SELECT o.id, c.name FROM orders.csv o LEFT JOIN customers.csv c ON o.customer_id = c.id" --format ndjson
+  cat data.csv | csv2json query "SELECT name FROM - WHERE city LIKE 'San%'"`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		options, err := buildOptions(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		var closers []func() error
+		defer func() {
+			for _, closeInput := range closers {
+				closeInput()
+			}
+		}()
+		open := func(path string) (io.Reader, converter.ConversionOptions, error) {
+			input, closeInput, err := openInput(path)
+			if err != nil {
+				return nil, options, err
+			}
+			closers = append(closers, closeInput)
+
+			input, _, err = converter.Decompress(input)
+			if err != nil {
+				return nil, options, fmt.Errorf("reading %s: %w", path, err)
+			}
+			return input, optionsForFile(cmd, options, path), nil
+		}
+
+		query := func(w io.Writer) error {
+			return converter.QueryCSV(args[0], open, w, options)
+		}
+		if err := writeOutput(cmd, queryOutput, options.OutputFormat != "ndjson", query); err != nil {
+			fmt.Fprintf(os.Stderr, "Error running query: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	queryCmd.Flags().StringVarP(&queryOutput, "output", "o", "", "Output JSON file (default: stdout)")
+
+	rootCmd.AddCommand(queryCmd)
+}
@@ -1,21 +1,66 @@
 package cmd
 
 import (
+	"context"
 	"csv2json/internal/converter"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
 var (
-	inputFile    string
-	outputFile   string
-	delimiter    string
-	noHeader     bool
-	outputFormat string
-	compact      bool
-	noInferTypes bool
+	inputFile      string
+	outputFile     string
+	delimiter      string
+	noHeader       bool
+	outputFormat   string
+	compact        bool
+	noInferTypes   bool
+	dialect        string
+	quoteChar      string
+	escapeChar     string
+	inputFormat    string
+	widths         string
+	widthsFile     string
+	sheet          string
+	compressOutput string
+	warnFormulas   bool
+	skipRows       int
+	commentPrefix  string
+	headerRow      int
+	headerRows     int
+	skipFooter     int
+	headerList     string
+	headersFile    string
+	columnNames    string
+	layout         string
+	keyColumn      string
+	duplicateKeys  string
+	dropKey        bool
+	unpivot        string
+	pivot          bool
+	varName        string
+	valueName      string
+	pivotAgg       string
+	groupBy        string
+	nestSpecs      []string
+	aggregate      string
+	groupSorted    bool
+	multiTable     bool
+	tableMarker    string
+	tableTitles    bool
+	follow         bool
+	joinFile       string
+	joinOn         string
+	joinHow        string
+	joinPrefix     string
+	pollInterval   time.Duration
 )
 
 var rootCmd = &cobra.Command{
@@ -26,80 +71,423 @@ var rootCmd = &cobra.Command{
 Examples:
   csv2json -i input.csv -o output.json
   csv2json -i data.csv --format object --delimiter ";"
-  csv2json -i file.csv --no-header --compact`,
+  csv2json -i file.csv --no-header --compact
+  csv2json -i feed.csv --no-header --headers id,name,email
+  csv2json -i dump.tsv --dialect postgres-text
+  csv2json -i feed.txt --delimiter "||"
+  csv2json -i report.txt --delimiter whitespace
+  csv2json -i extract.txt --input-format fixed --widths name:1-20,age:21-23
+  csv2json -i report.xlsx --sheet Summary
+  csv2json -i statement.csv --skip-rows 3 --skip-footer 1 --comment '#'
+  csv2json -i users.csv --format keyed --key id --drop-key
+  csv2json -i settings.csv --layout keyvalue --no-header
+  csv2json -i orders.csv --group-by order_id --nest items=item_sku,qty
+  csv2json -i sales.csv --unpivot id_cols=region --var month --value amount
+  csv2json -i employees.csv --aggregate "department: count, sum(salary), avg(age), min(join_date), max(join_date)"
+  csv2json -i workbook.csv --multi-table --table-titles
+  csv2json -i data.csv.gz -o data.json
+  csv2json -i data.csv -o data.json.gz
+  curl -s https://example.com/data.csv | csv2json --compact | jq '.[0]'
+  csv2json -i audit.csv --follow | jq -c 'select(.level == "error")'
+  csv2json -i orders.csv --join customers.csv --on customer_id --how left`,
 	Run: func(cmd *cobra.Command, args []string) {
+		// Without -i, read piped input; on an interactive terminal show usage instead
 		if inputFile == "" {
-			fmt.Println("Error: input file is required")
-			cmd.Help()
+			if stat, err := os.Stdin.Stat(); err == nil && stat.Mode()&os.ModeCharDevice != 0 {
+				fmt.Fprintln(os.Stderr, "Error: input file is required")
+				cmd.Help()
+				os.Exit(1)
+			}
+		}
+
+		baseOptions, err := buildOptions(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
+		options := optionsForFile(cmd, baseOptions, inputFile)
 
-		// Parse delimiter
-		var delimiterRune rune = ','
-		if delimiter != "" {
-			if len(delimiter) == 1 {
-				delimiterRune = rune(delimiter[0])
-			} else if delimiter == "\\t" {
-				delimiterRune = '\t'
-			} else {
-				fmt.Printf("Error: invalid delimiter '%s'\n", delimiter)
+		if follow {
+			if joinFile != "" {
+				fmt.Fprintln(os.Stderr, "Error: --join cannot be combined with --follow")
 				os.Exit(1)
 			}
+			if inputFile == "" || inputFile == "-" {
+				fmt.Fprintln(os.Stderr, "Error: --follow requires an input file")
+				os.Exit(1)
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			if err := followInput(ctx, cmd, options); err != nil {
+				fmt.Fprintf(os.Stderr, "Error following input file: %v\n", err)
+				os.Exit(1)
+			}
+			return
 		}
 
-		// Set up conversion options
-		options := converter.ConversionOptions{
-			Delimiter:    delimiterRune,
-			HasHeader:    !noHeader,
-			OutputFormat: outputFormat,
-			PrettyPrint:  !compact,
-			InferTypes:   !noInferTypes,
-		}
-
-		// Open input file
-		file, err := os.Open(inputFile)
+		// Open input file, reading stdin for "-" or when no file is given
+		input, closeInput, err := openInput(inputFile)
 		if err != nil {
-			fmt.Printf("Error opening input file: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Error opening input file: %v\n", err)
 			os.Exit(1)
 		}
-		defer file.Close()
+		defer closeInput()
 
-		// Convert CSV to JSON
-		jsonData, err := converter.ConvertCSVToJSON(file, options)
+		// Transparently decompress gzip and bzip2 input
+		input, stats, err := converter.Decompress(input)
 		if err != nil {
-			fmt.Printf("Error converting CSV to JSON: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Error reading compressed input: %v\n", err)
 			os.Exit(1)
 		}
 
-		// Output result
-		if outputFile != "" {
-			err = os.WriteFile(outputFile, jsonData, 0644)
-			if err != nil {
-				fmt.Printf("Error writing output file: %v\n", err)
+		convert := func(w io.Writer) error {
+			return converter.ConvertCSVToJSONStream(input, w, options)
+		}
+		if joinFile != "" {
+			if convert, err = joinConverter(cmd, input, baseOptions); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 				os.Exit(1)
 			}
-			fmt.Printf("Successfully converted %s to %s\n", inputFile, outputFile)
-		} else {
-			fmt.Println(string(jsonData))
+		}
+		if err := writeOutput(cmd, outputFile, options.OutputFormat != "ndjson", convert); err != nil {
+			fmt.Fprintf(os.Stderr, "Error converting CSV to JSON: %v\n", err)
+			os.Exit(1)
+		}
+		if outputFile == "" || outputFile == "-" {
+			return
+		}
+		fmt.Fprintf(os.Stderr, "Successfully converted %s to %s\n", inputName(inputFile), outputFile)
+		if stats.Compression != "" {
+			fmt.Fprintf(os.Stderr, "Input %s: %d bytes compressed, %d bytes uncompressed\n",
+				stats.Compression, stats.CompressedBytes, stats.UncompressedBytes)
 		}
 	},
 }
 
+// followInput streams the records appended to the input file to the output
+// file, compressed as requested, or to stdout until ctx is cancelled
+func followInput(ctx context.Context, cmd *cobra.Command, options converter.ConversionOptions) error {
+	return writeOutput(cmd, outputFile, false, func(w io.Writer) error {
+		return converter.FollowCSV(ctx, inputFile, w, options, pollInterval)
+	})
+}
+
+// openInput opens the named file, or stdin when the name is empty or "-"
+func openInput(name string) (io.Reader, func() error, error) {
+	if name == "" || name == "-" {
+		return os.Stdin, func() error { return nil }, nil
+	}
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, file.Close, nil
+}
+
+// joinConverter opens the --join file and returns a conversion that merges
+// its matching rows into the input's records. Each file is read with options
+// for its own format; the row and header flags describe --input only.
+func joinConverter(cmd *cobra.Command, input io.Reader, options converter.ConversionOptions) (func(io.Writer) error, error) {
+	if joinOn == "" {
+		return nil, fmt.Errorf("--join requires --on naming the key column")
+	}
+	leftKey, rightKey, err := converter.ParseJoinOn(joinOn)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(joinFile)
+	if err != nil {
+		return nil, err
+	}
+	joined, _, err := converter.Decompress(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("reading %s: %w", joinFile, err)
+	}
+
+	inputOptions := optionsForFile(cmd, options, inputFile)
+	joinedOptions := optionsForFile(cmd, options, joinFile)
+	joinedOptions.InputFormat = "csv"
+	if isXLSXPath(joinFile) {
+		joinedOptions.InputFormat = "xlsx"
+	}
+	joinedOptions.Sheet, joinedOptions.FixedWidths, joinedOptions.Headers = "", nil, nil
+	joinedOptions.SkipRows, joinedOptions.HeaderRow, joinedOptions.HeaderRows, joinedOptions.SkipFooter = 0, 0, 0, 0
+
+	// File sizes let the smaller side be held in memory
+	left := converter.JoinInput{Name: inputName(inputFile), Reader: input, Options: &inputOptions}
+	if stat, err := os.Stat(inputFile); err == nil && inputFile != "-" {
+		left.Size = stat.Size()
+	}
+	right := converter.JoinInput{Name: joinFile, Reader: joined, Options: &joinedOptions}
+	if stat, err := file.Stat(); err == nil {
+		right.Size = stat.Size()
+	}
+
+	joinOptions := converter.JoinOptions{
+		ConversionOptions: inputOptions,
+		LeftKey:           leftKey,
+		RightKey:          rightKey,
+		How:               joinHow,
+		Prefix:            joinPrefix,
+	}
+	return func(w io.Writer) error {
+		defer file.Close()
+		return converter.JoinCSVToJSON(left, right, w, joinOptions)
+	}, nil
+}
+
+// inputName returns a display name for the input file
+func inputName(name string) string {
+	if name == "" || name == "-" {
+		return "stdin"
+	}
+	return name
+}
+
+// writeOutput runs write against stdout, or against the output file through the
+// compressor selected by --compress or the file name. Stdout stays pure output;
+// trailingNewline terminates documents that do not end in a newline.
+func writeOutput(cmd *cobra.Command, path string, trailingNewline bool, write func(io.Writer) error) error {
+	if path == "" || path == "-" {
+		if err := write(os.Stdout); err != nil {
+			return err
+		}
+		if trailingNewline {
+			fmt.Fprintln(os.Stdout)
+		}
+		return nil
+	}
+
+	compression := compressOutput
+	if !cmd.Flags().Changed("compress") {
+		compression = converter.CompressionForPath(path)
+	}
+	return writeOutputFile(path, compression, write)
+}
+
+// writeOutputFile streams the output of write to path through the requested compressor
+func writeOutputFile(path string, compression string, write func(io.Writer) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer, err := converter.NewCompressedWriter(file, compression)
+	if err != nil {
+		return err
+	}
+	if err := write(writer); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// buildOptions assembles conversion options from the shared conversion flags
+func buildOptions(cmd *cobra.Command) (converter.ConversionOptions, error) {
+	quoteRune, err := parseCharFlag("quote", quoteChar)
+	if err != nil {
+		return converter.ConversionOptions{}, err
+	}
+	escapeRune, err := parseCharFlag("escape", escapeChar)
+	if err != nil {
+		return converter.ConversionOptions{}, err
+	}
+
+	options := converter.ConversionOptions{
+		HasHeader:     !noHeader,
+		OutputFormat:  outputFormat,
+		PrettyPrint:   !compact,
+		InferTypes:    !noInferTypes,
+		Dialect:       dialect,
+		Quote:         quoteRune,
+		Escape:        escapeRune,
+		InputFormat:   inputFormat,
+		Sheet:         sheet,
+		SkipRows:      skipRows,
+		Comment:       commentPrefix,
+		HeaderRow:     headerRow,
+		HeaderRows:    headerRows,
+		SkipFooter:    skipFooter,
+		ColumnNames:   columnNames,
+		Layout:        layout,
+		Key:           keyColumn,
+		DuplicateKeys: duplicateKeys,
+		DropKey:       dropKey,
+		GroupBy:       converter.ParseHeaderList(groupBy),
+		GroupSorted:   groupSorted,
+		MultiTable:    multiTable || tableMarker != "",
+		TableMarker:   tableMarker,
+		TableTitles:   tableTitles,
+	}
+
+	// --unpivot takes the identifier columns, optionally written as id_cols=a,b
+	switch {
+	case cmd.Flags().Changed("unpivot") && pivot:
+		return options, fmt.Errorf("--unpivot and --pivot cannot be combined")
+	case cmd.Flags().Changed("unpivot"):
+		options.Reshape = "unpivot"
+		options.IDColumns = converter.ParseHeaderList(strings.TrimPrefix(strings.TrimSpace(unpivot), "id_cols="))
+	case pivot:
+		options.Reshape = "pivot"
+	}
+	options.VarName, options.ValueName, options.PivotAggregate = varName, valueName, pivotAgg
+
+	for _, spec := range nestSpecs {
+		nest, err := converter.ParseNestSpec(spec)
+		if err != nil {
+			return options, err
+		}
+		options.Nest = append(options.Nest, nest)
+	}
+
+	if aggregate != "" {
+		spec, err := converter.ParseAggregateSpec(aggregate)
+		if err != nil {
+			return options, err
+		}
+		options.Aggregate = &spec
+	}
+
+	// Supplied headers come from the flag or a headers file
+	if headersFile != "" {
+		data, err := os.ReadFile(headersFile)
+		if err != nil {
+			return options, fmt.Errorf("reading headers file: %w", err)
+		}
+		headerList = string(data)
+	}
+	options.Headers = converter.ParseHeaderList(headerList)
+
+	if warnFormulas {
+		options.DetectFormulas = true
+		options.OnWarning = func(message string) {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", message)
+		}
+	}
+
+	// Load the fixed-width column spec from the flag or a spec file
+	spec := widths
+	if widthsFile != "" {
+		data, err := os.ReadFile(widthsFile)
+		if err != nil {
+			return options, fmt.Errorf("reading widths file: %w", err)
+		}
+		spec = string(data)
+	}
+	if spec != "" {
+		columns, err := converter.ParseFixedWidthSpec(spec)
+		if err != nil {
+			return options, fmt.Errorf("invalid widths: %w", err)
+		}
+		options.FixedWidths = columns
+		if inputFormat == "csv" {
+			options.InputFormat = "fixed"
+		}
+	}
+
+	// Let the dialect preset choose the delimiter unless one was given explicitly
+	if dialect == "" || cmd.Flags().Changed("delimiter") {
+		if err := options.SetDelimiter(delimiter); err != nil {
+			return options, fmt.Errorf("invalid delimiter '%s': %w", delimiter, err)
+		}
+	}
+
+	return options, nil
+}
+
+// optionsForFile selects xlsx input from the file extension unless --input-format
+// was given, and names the file in warnings
+func optionsForFile(cmd *cobra.Command, options converter.ConversionOptions, path string) converter.ConversionOptions {
+	if !cmd.Flags().Changed("input-format") && isXLSXPath(path) {
+		options.InputFormat = "xlsx"
+	}
+	if warn := options.OnWarning; warn != nil && path != "" && path != "-" {
+		options.OnWarning = func(message string) { warn(path + ": " + message) }
+	}
+	return options
+}
+
+// isXLSXPath reports whether a file name, possibly compressed, names an xlsx workbook
+func isXLSXPath(path string) bool {
+	name := strings.ToLower(strings.TrimSuffix(strings.TrimSuffix(path, ".gz"), ".bz2"))
+	return strings.HasSuffix(name, ".xlsx")
+}
+
+// parseCharFlag parses a single-character flag value, accepting "\\t" for tab
+func parseCharFlag(name, value string) (rune, error) {
+	switch {
+	case value == "":
+		return 0, nil
+	case value == "\\t":
+		return '\t', nil
+	case len([]rune(value)) == 1:
+		return []rune(value)[0], nil
+	}
+	return 0, fmt.Errorf("invalid %s character '%s'", name, value)
+}
+
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
 
 func init() {
-	rootCmd.Flags().StringVarP(&inputFile, "input", "i", "", "Input CSV file (required)")
+	rootCmd.Flags().StringVarP(&inputFile, "input", "i", "", "Input CSV file, optionally gzip or bzip2 compressed (default: stdin, also '-')")
 	rootCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output JSON file (optional, prints to stdout if not specified)")
-	rootCmd.Flags().StringVarP(&delimiter, "delimiter", "d", ",", "CSV delimiter (default: comma)")
-	rootCmd.Flags().BoolVar(&noHeader, "no-header", false, "CSV file has no header row")
-	rootCmd.Flags().StringVar(&outputFormat, "format", "array", "Output format: 'array' or 'object'")
-	rootCmd.Flags().BoolVar(&compact, "compact", false, "Compact JSON output (no pretty printing)")
-	rootCmd.Flags().BoolVar(&noInferTypes, "no-infer-types", false, "Don't infer data types, keep all values as strings")
 
-	rootCmd.MarkFlagRequired("input")
+	rootCmd.Flags().BoolVar(&follow, "follow", false, "Keep reading appended rows like 'tail -f' and emit each record as NDJSON")
+	rootCmd.Flags().DurationVar(&pollInterval, "poll-interval", 500*time.Millisecond, "How often --follow checks the input for new data")
+	rootCmd.Flags().StringVar(&joinFile, "join", "", "CSV file whose matching rows are merged into each record (see --on and --how)")
+	rootCmd.Flags().StringVar(&joinOn, "on", "", "Key column for --join, or 'input_column=joined_column' when the names differ")
+	rootCmd.Flags().StringVar(&joinHow, "how", "inner", "Join type: 'inner' drops rows without a match, 'left' keeps them with null joined fields")
+	rootCmd.Flags().StringVar(&joinPrefix, "join-prefix", "", "Prefix for joined fields whose names collide with the input's (default: the joined file's name and '_')")
+
+	// Conversion flags are shared with subcommands
+	flags := rootCmd.PersistentFlags()
+	flags.StringVarP(&delimiter, "delimiter", "d", ",", "Delimiter: a character, 'tab', 'pipe', 'whitespace', a multi-character separator or 'regex:<pattern>'")
+	flags.BoolVar(&noHeader, "no-header", false, "CSV file has no header row")
+	flags.StringVar(&outputFormat, "format", "array", "Output format: 'array', 'object', 'ndjson' or 'keyed' (an object indexed by --key)")
+	flags.BoolVar(&compact, "compact", false, "Compact JSON output (no pretty printing)")
+	flags.BoolVar(&noInferTypes, "no-infer-types", false, "Don't infer data types, keep all values as strings")
+	flags.StringVar(&dialect, "dialect", "", "Input dialect: 'csv', 'mysql' or 'postgres-text'")
+	flags.StringVar(&quoteChar, "quote", "", "Quote character (default from dialect)")
+	flags.StringVar(&escapeChar, "escape", "", "Escape character, e.g. '\\' (default: quotes are doubled)")
+	flags.StringVar(&inputFormat, "input-format", "csv", "Input format: 'csv', 'fixed' or 'xlsx' (detected from the .xlsx extension)")
+	flags.StringVar(&widths, "widths", "", "Fixed-width column spec, e.g. 'name:1-20,age:21-23'")
+	flags.StringVar(&widthsFile, "widths-file", "", "File containing the fixed-width column spec, one column per line")
+	flags.StringVar(&sheet, "sheet", "", "Worksheet name or 1-based index for xlsx input (default: first sheet)")
+	flags.StringVar(&headerList, "headers", "", "Comma-separated column names, e.g. 'id,name,email' (replaces the header row if there is one)")
+	flags.StringVar(&headersFile, "headers-file", "", "File listing the column names, one per line or comma-separated")
+	flags.StringVar(&columnNames, "column-names", converter.DefaultColumnNames, "Names for --no-header columns: a template with {n} (1-based) or {i} (0-based), or 'letters' for A, B, ..., AA")
+	flags.IntVar(&skipRows, "skip-rows", 0, "Skip this many lines (e.g. report titles) before the header")
+	flags.StringVar(&commentPrefix, "comment", "", "Skip lines starting with this prefix, e.g. '#' or '//'")
+	flags.IntVar(&headerRow, "header-row", 0, "1-based row holding the header; rows above it are dropped")
+	flags.IntVar(&headerRows, "header-rows", 1, "Number of stacked header rows combined into keys like 'Q1.Revenue'")
+	flags.IntVar(&skipFooter, "skip-footer", 0, "Drop this many rows (e.g. a 'Total' line) from the end of the input")
+	flags.StringVar(&layout, "layout", "table", "Input layout: 'table', 'keyvalue' (key,value rows become one object; add --no-header when the first row is not a header) or 'transpose' (one record per column)")
+	flags.StringVar(&keyColumn, "key", "", "Column whose values index the keyed output format")
+	flags.StringVar(&duplicateKeys, "duplicate-keys", "error", "Keyed output policy for repeated keys: 'error', 'last' or 'collect'")
+	flags.BoolVar(&dropKey, "drop-key", false, "Remove the --key column from the indexed records")
+	flags.StringVar(&unpivot, "unpivot", "", "Turn wide columns into long rows, keeping these identifier columns, e.g. 'id_cols=region'")
+	flags.BoolVar(&pivot, "pivot", false, "Turn long rows into wide columns named by --var holding --value (the other columns identify a row)")
+	flags.StringVar(&varName, "var", converter.DefaultVarName, "Long-form column holding the wide column names (with --unpivot or --pivot)")
+	flags.StringVar(&valueName, "value", converter.DefaultValueName, "Long-form column holding the values (with --unpivot or --pivot)")
+	flags.StringVar(&pivotAgg, "pivot-agg", "first", "Combine values that --pivot puts in the same cell: count, sum, avg, min, max, first or last")
+	flags.StringVar(&groupBy, "group-by", "", "Merge rows sharing these comma-separated columns into one document")
+	flags.StringArrayVar(&nestSpecs, "nest", nil, "Collect columns of grouped rows into a child array, e.g. 'items=item_sku,qty' (repeatable)")
+	flags.StringVar(&aggregate, "aggregate", "", "Output a summary instead of rows, e.g. 'department: count, sum(salary), avg(age)' (count, sum, avg, min, max, first, last)")
+	flags.BoolVar(&groupSorted, "sorted", false, "Input is sorted by the --group-by columns, so groups are streamed instead of held in memory")
+	flags.BoolVar(&multiTable, "multi-table", false, "Split the input into tables at blank rows and output {\"<table>\": [...]}")
+	flags.StringVar(&tableMarker, "table-marker", "", "Start a new table at rows beginning with this prefix; the rest of the row names the table (implies --multi-table)")
+	flags.BoolVar(&tableTitles, "table-titles", false, "Name each table after its first row (with --multi-table)")
+	flags.BoolVar(&warnFormulas, "warn-formulas", false, "Warn on stderr about cells starting with '=', '+', '-' or '@' that spreadsheets would run as formulas")
+	flags.StringVar(&compressOutput, "compress", "", "Compress output files: 'gzip' or 'none' (default: gzip for .gz output names)")
 }
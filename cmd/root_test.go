@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeXLSX writes a one-sheet workbook holding rows of inline strings
+func writeXLSX(t *testing.T, path string, rows [][]string) {
+	t.Helper()
+	var sheet strings.Builder
+	for _, row := range rows {
+		sheet.WriteString("<row>")
+		for _, cell := range row {
+			sheet.WriteString(`<c t="inlineStr"><is><t>` + cell + `</t></is></c>`)
+		}
+		sheet.WriteString("</row>")
+	}
+	parts := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Target="worksheets/sheet1.xml"/></Relationships>`,
+		"xl/worksheets/sheet1.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` +
+			sheet.String() + `</sheetData></worksheet>`,
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range parts {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestJoinConverterMixedFormats(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	ordersCSV := write("orders.csv", "Order export\n\norder_id,customer_id\n10,1\n11,2\n")
+	plainCSV := write("plain.csv", "order_id,customer_id\n10,1\n11,2\n")
+	customersCSV := write("customers.csv", "customer_id,name\n1,Acme\n2,Globex\n")
+	ordersXLSX := filepath.Join(dir, "orders.xlsx")
+	writeXLSX(t, ordersXLSX, [][]string{{"order_id", "customer_id"}, {"10", "1"}, {"11", "2"}})
+	customersXLSX := filepath.Join(dir, "customers.xlsx")
+	writeXLSX(t, customersXLSX, [][]string{{"customer_id", "name"}, {"1", "Acme"}, {"2", "Globex"}})
+
+	tests := []struct {
+		name  string
+		input string
+		join  string
+		skip  int
+	}{
+		{"xlsx input with csv join", ordersXLSX, customersCSV, 0},
+		{"csv input with xlsx join", plainCSV, customersXLSX, 0},
+		{"skipped input rows do not apply to the join", ordersCSV, customersCSV, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputFile, joinFile, joinOn, joinHow, compact, skipRows = tt.input, tt.join, "customer_id", "inner", true, tt.skip
+			defer func() {
+				inputFile, joinFile, joinOn, compact, skipRows = "", "", "", false, 0
+			}()
+
+			options, err := buildOptions(rootCmd)
+			if err != nil {
+				t.Fatal(err)
+			}
+			file, err := os.Open(inputFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+
+			convert, err := joinConverter(rootCmd, file, options)
+			if err != nil {
+				t.Fatalf("joinConverter() error = %v", err)
+			}
+			var out bytes.Buffer
+			if err := convert(&out); err != nil {
+				t.Fatalf("join error = %v", err)
+			}
+			expected := `[{"customer_id":1,"name":"Acme","order_id":10},{"customer_id":2,"name":"Globex","order_id":11}]`
+			if out.String() != expected {
+				t.Errorf("join = %s, want %s", out.String(), expected)
+			}
+		})
+	}
+}
+
+func TestFollowWritesOutputFile(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "audit.csv")
+	if err := os.WriteFile(input, []byte("id,level\n1,info\n2,error\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	expected := "{\"id\":1,\"level\":\"info\"}\n{\"id\":2,\"level\":\"error\"}\n"
+
+	defer func(in, out string, interval time.Duration) {
+		inputFile, outputFile, pollInterval = in, out, interval
+	}(inputFile, outputFile, pollInterval)
+	inputFile, pollInterval = input, 10*time.Millisecond
+
+	for _, name := range []string{"audit.json", "audit.json.gz"} {
+		outputFile = filepath.Join(dir, name)
+		options, err := buildOptions(rootCmd)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() { done <- followInput(ctx, rootCmd, options) }()
+
+		// Records must reach the file while following, not only once it stops
+		var got string
+		for deadline := time.Now().Add(5 * time.Second); got != expected && time.Now().Before(deadline); {
+			time.Sleep(10 * time.Millisecond)
+			got = readFollowOutput(outputFile)
+		}
+		cancel()
+		if err := <-done; err != nil {
+			t.Fatalf("%s: followInput() error = %v", name, err)
+		}
+		if got != expected {
+			t.Errorf("%s while following = %q, want %q", name, got, expected)
+		}
+		if got = readFollowOutput(outputFile); got != expected {
+			t.Errorf("%s after stopping = %q, want %q", name, got, expected)
+		}
+	}
+}
+
+// readFollowOutput returns what can be read so far from a plain or gzipped output file
+func readFollowOutput(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil || !strings.HasSuffix(path, ".gz") {
+		return string(data)
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return ""
+	}
+	text, _ := io.ReadAll(gz)
+	return string(text)
+}
+
+func TestWriteOutputFileCompression(t *testing.T) {
+	const data = `[{"id":1}]`
+	dir := t.TempDir()
+
+	tests := []struct {
+		name        string
+		compression string
+		gzipped     bool
+	}{
+		{"out.json", "", false},
+		{"out.json", "none", false},
+		{"out.json.gz", "gzip", true},
+		{"forced.json", "gzip", true},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.name)
+		err := writeOutputFile(path, tt.compression, func(w io.Writer) error {
+			_, err := io.WriteString(w, data)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("%s (%q): writeOutputFile() error = %v", tt.name, tt.compression, err)
+		}
+
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if isGzip := bytes.HasPrefix(raw, []byte{0x1f, 0x8b}); isGzip != tt.gzipped {
+			t.Errorf("%s (%q): gzipped = %v, want %v", tt.name, tt.compression, isGzip, tt.gzipped)
+		}
+		got := raw
+		if tt.gzipped {
+			gz, err := gzip.NewReader(bytes.NewReader(raw))
+			if err != nil {
+				t.Fatalf("%s (%q): gzip.NewReader() error = %v", tt.name, tt.compression, err)
+			}
+			if got, err = io.ReadAll(gz); err != nil {
+				t.Fatalf("%s (%q): reading gzip error = %v", tt.name, tt.compression, err)
+			}
+		}
+		if string(got) != data {
+			t.Errorf("%s (%q): read back %q, want %q", tt.name, tt.compression, got, data)
+		}
+	}
+
+	if err := writeOutputFile(filepath.Join(dir, "x.json"), "zstd", func(io.Writer) error { return nil }); err == nil {
+		t.Error("expected an error for an unsupported compression")
+	}
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"csv2json/internal/converter"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	tocsvInput  string
+	tocsvOutput string
+	joiner      string
+	keySep      string
+	crlf        bool
+	sanitize    bool
+)
+
+var tocsvCmd = &cobra.Command{
+	Use:   "tocsv",
+	Short: "Convert JSON or NDJSON back to CSV",
+	Long: `Convert a JSON array of objects, the column-oriented 'object' format or NDJSON
+to RFC 4180 CSV. The header is the union of keys in first-seen order, nested
+objects become dotted keys and arrays are joined with --joiner.
+
+Examples:
+  csv2json tocsv -i data.json -o data.csv
+  curl -s https://example.com/api/items | csv2json tocsv --joiner "|" > items.csv`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		options := converter.DefaultJSONToCSVOptions()
+		options.ArrayJoiner = joiner
+		options.KeySeparator = keySep
+		options.UseCRLF = crlf
+		options.SanitizeFormulas = sanitize
+
+		var delimiterOptions converter.ConversionOptions
+		if err := delimiterOptions.SetDelimiter(delimiter); err != nil || delimiterOptions.Delimiter == 0 {
+			fmt.Fprintf(os.Stderr, "Error: invalid delimiter '%s': CSV output needs a single character\n", delimiter)
+			os.Exit(1)
+		}
+		options.Delimiter = delimiterOptions.Delimiter
+
+		input, closeInput, err := openInput(tocsvInput)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening input file: %v\n", err)
+			os.Exit(1)
+		}
+		defer closeInput()
+
+		input, _, err = converter.Decompress(input)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading compressed input: %v\n", err)
+			os.Exit(1)
+		}
+
+		convert := func(w io.Writer) error {
+			return converter.ConvertJSONToCSV(input, w, options)
+		}
+		if err := writeOutput(cmd, tocsvOutput, false, convert); err != nil {
+			fmt.Fprintf(os.Stderr, "Error converting JSON to CSV: %v\n", err)
+			os.Exit(1)
+		}
+		if tocsvOutput != "" && tocsvOutput != "-" {
+			fmt.Fprintf(os.Stderr, "Successfully converted %s to %s\n", inputName(tocsvInput), tocsvOutput)
+		}
+	},
+}
+
+func init() {
+	tocsvCmd.Flags().StringVarP(&tocsvInput, "input", "i", "", "Input JSON or NDJSON file (default: stdin)")
+	tocsvCmd.Flags().StringVarP(&tocsvOutput, "output", "o", "", "Output CSV file (default: stdout)")
+	tocsvCmd.Flags().StringVar(&joiner, "joiner", ";", "Separator used to join array elements into one cell")
+	tocsvCmd.Flags().StringVar(&keySep, "key-separator", ".", "Separator between nested object keys in column names")
+	tocsvCmd.Flags().BoolVar(&crlf, "crlf", false, "End lines with CRLF as in RFC 4180")
+
+	tocsvCmd.Flags().BoolVar(&sanitize, "sanitize-formulas", false, "Prefix cells starting with '=', '+', '-' or '@' with ' so spreadsheets show them as text")
+
+	rootCmd.AddCommand(tocsvCmd)
+}
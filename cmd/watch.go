@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"context"
+	"csv2json/internal/watcher"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	watchOutDir   string
+	watchArchive  string
+	watchInterval time.Duration
+	watchStable   time.Duration
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch <dir>",
+	Short: "Watch a directory and convert new or changed CSV files",
+	Long: `Poll a directory for new or changed CSV files and convert each one once its
+size has stopped changing for the --stable period.
+
+Outputs mirror the directory structure under --out-dir. With --archive,
+converted inputs are moved to the archive directory afterwards.
+
+Examples:
+  csv2json watch incoming/ --out-dir converted/
+  csv2json watch incoming/ --out-dir converted/ --archive processed/ --stable 10s`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := args[0]
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			fmt.Fprintf(os.Stderr, "Error: %s is not a directory\n", dir)
+			os.Exit(1)
+		}
+
+		options, err := buildOptions(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		logger := log.New(os.Stderr, "", log.LstdFlags)
+		outDir := watchOutDir
+		if outDir == "" {
+			outDir = dir
+		}
+
+		convert := func(path string) error {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			output := filepath.Join(outDir, filepath.Dir(rel), jsonName(filepath.Base(rel), compressOutput))
+
+			// Files already converted before the watcher started are left alone
+			if watchArchive == "" && upToDate(path, output) {
+				return nil
+			}
+
+			start := time.Now()
+			if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+				return err
+			}
+			if err := convertFile(path, output, optionsForFile(cmd, options, path), compressOutput); err != nil {
+				os.Remove(output)
+				return fmt.Errorf("converting %s: %w", path, err)
+			}
+			logger.Printf("converted %s -> %s (%s)", path, output, time.Since(start).Round(time.Millisecond))
+
+			if watchArchive != "" {
+				archived := filepath.Join(watchArchive, rel)
+				if err := os.MkdirAll(filepath.Dir(archived), 0755); err != nil {
+					return err
+				}
+				if err := os.Rename(path, archived); err != nil {
+					return fmt.Errorf("archiving %s: %w", path, err)
+				}
+				logger.Printf("archived %s -> %s", path, archived)
+			}
+			return nil
+		}
+
+		w := watcher.New(dir, watchMatch(dir, watchOutDir, watchArchive), watchInterval, watchStable)
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		logger.Printf("watching %s (interval %s, stable after %s)", dir, watchInterval, watchStable)
+		w.Run(ctx, convert, func(err error) {
+			logger.Printf("error: %v", err)
+		})
+		logger.Printf("stopped watching %s", dir)
+	},
+}
+
+// watchMatch accepts the batch inputs under dir, leaving out the output and
+// archive directories when they are nested inside it, so that archived inputs
+// are not picked up and converted again
+func watchMatch(dir string, skip ...string) func(path string) bool {
+	root := absPath(dir)
+	var skipDirs []string
+	for _, skipDir := range skip {
+		if skipDir != "" && absPath(skipDir) != root {
+			skipDirs = append(skipDirs, absPath(skipDir))
+		}
+	}
+
+	return func(path string) bool {
+		if !isBatchInput(path) {
+			return false
+		}
+		path = absPath(path)
+		for _, skipDir := range skipDirs {
+			if rel, err := filepath.Rel(skipDir, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func init() {
+	watchCmd.Flags().StringVar(&watchOutDir, "out-dir", "", "Directory for JSON outputs (default: the watched directory)")
+	watchCmd.Flags().StringVar(&watchArchive, "archive", "", "Move inputs to this directory after converting them")
+	watchCmd.Flags().DurationVar(&watchInterval, "interval", 2*time.Second, "How often to poll the directory")
+	watchCmd.Flags().DurationVar(&watchStable, "stable", 5*time.Second, "How long a file's size must stay unchanged before it is converted")
+
+	rootCmd.AddCommand(watchCmd)
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestWatchMatchSkipsNestedDirectories(t *testing.T) {
+	dir := t.TempDir()
+	match := watchMatch(dir, filepath.Join(dir, "converted"), filepath.Join(dir, "processed"))
+
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{filepath.Join(dir, "a.csv"), true},
+		{filepath.Join(dir, "sub", "b.csv.gz"), true},
+		{filepath.Join(dir, "processed-2024", "c.csv"), true},
+		{filepath.Join(dir, "notes.txt"), false},
+		{filepath.Join(dir, "processed", "a.csv"), false},
+		{filepath.Join(dir, "processed", "processed", "a.csv"), false},
+		{filepath.Join(dir, "converted", "sub", "b.csv"), false},
+	}
+	for _, tt := range tests {
+		if got := match(tt.path); got != tt.expected {
+			t.Errorf("match(%s) = %v, want %v", tt.path, got, tt.expected)
+		}
+	}
+
+	// Outputs written next to the inputs leave the whole directory watched
+	if !watchMatch(dir, dir, "")(filepath.Join(dir, "a.csv")) {
+		t.Error("the watched directory itself must not be skipped")
+	}
+}
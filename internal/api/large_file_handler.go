@@ -4,6 +4,7 @@ import (
 	"csv2json/internal/converter"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
@@ -15,6 +16,9 @@ import (
 func uploadHandlerLarge(c *gin.Context) {
 	file, header, err := c.Request.FormFile("file")
 	if err != nil {
+		if abortTooLarge(c, err) {
+			return
+		}
 		c.JSON(http.StatusBadRequest, ConvertResponse{
 			Success: false,
 			Error:   "No file uploaded: " + err.Error(),
@@ -24,39 +28,40 @@ func uploadHandlerLarge(c *gin.Context) {
 	defer file.Close()
 
 	// Parse options from form data
-	options := converter.DefaultOptions()
-	
-	if delimiter := c.PostForm("delimiter"); delimiter != "" {
-		if len(delimiter) == 1 {
-			options.Delimiter = rune(delimiter[0])
-		}
-	}
-	
-	if hasHeader := c.PostForm("has_header"); hasHeader != "" {
-		if val, err := strconv.ParseBool(hasHeader); err == nil {
-			options.HasHeader = val
-		}
+	options, err := formOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ConvertResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
 	}
-	
-	if outputFormat := c.PostForm("output_format"); outputFormat != "" {
-		options.OutputFormat = outputFormat
+	baseName := strings.TrimSuffix(strings.TrimSuffix(header.Filename, ".gz"), ".bz2")
+	if options.InputFormat == "" && strings.HasSuffix(strings.ToLower(baseName), ".xlsx") {
+		options.InputFormat = "xlsx"
 	}
-	
-	if prettyPrint := c.PostForm("pretty_print"); prettyPrint != "" {
-		if val, err := strconv.ParseBool(prettyPrint); err == nil {
-			options.PrettyPrint = val
-		}
+
+	warnings := collectWarnings(&options)
+
+	// Transparently decompress gzip and bzip2 uploads, up to MaxDecompressedSize
+	input, stats, err := converter.Decompress(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ConvertResponse{
+			Success: false,
+			Error:   "Invalid compressed file: " + err.Error(),
+		})
+		return
 	}
-	
-	if inferTypes := c.PostForm("infer_types"); inferTypes != "" {
-		if val, err := strconv.ParseBool(inferTypes); err == nil {
-			options.InferTypes = val
-		}
+	if stats.Compression != "" {
+		input = limitDecompressed(input)
 	}
 
 	// Convert CSV to JSON
-	jsonData, err := converter.ConvertCSVToJSON(file, options)
+	jsonData, err := converter.ConvertCSVToJSON(input, options)
 	if err != nil {
+		if abortTooLarge(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, ConvertResponse{
 			Success: false,
 			Error:   "Conversion failed: " + err.Error(),
@@ -64,6 +69,12 @@ func uploadHandlerLarge(c *gin.Context) {
 		return
 	}
 
+	// NDJSON is not a single JSON document, so it is returned as-is
+	if options.OutputFormat == "ndjson" {
+		c.Data(http.StatusOK, "application/x-ndjson", jsonData)
+		return
+	}
+
 	// For large files (>10MB), trigger automatic download instead of temp storage
 	if len(jsonData) > 10*1024*1024 {
 		// Calculate processing stats - count JSON objects properly
@@ -78,11 +89,24 @@ func uploadHandlerLarge(c *gin.Context) {
 		}
 
 		// Set headers for direct download
-		filename := strings.TrimSuffix(header.Filename, ".csv") + ".json"
+		filename := strings.TrimSuffix(strings.TrimSuffix(baseName, ".csv"), ".xlsx") + ".json"
 		c.Header("Content-Description", "File Transfer")
 		c.Header("Content-Transfer-Encoding", "binary")
 		c.Header("Content-Disposition", "attachment; filename="+filename)
 		c.Header("Content-Type", "application/json")
+		c.Header("Vary", "Accept-Encoding")
+
+		// Stream a gzip-compressed body when the client accepts it
+		if acceptsGzip(c.GetHeader("Accept-Encoding")) {
+			c.Header("Content-Encoding", "gzip")
+			c.Status(http.StatusOK)
+			// The status is already sent, so a failed write can only be recorded
+			if err := writeGzip(c.Writer, jsonData); err != nil {
+				c.Error(fmt.Errorf("writing gzip response: %w", err))
+			}
+			return
+		}
+
 		c.Header("Content-Length", fmt.Sprintf("%d", len(jsonData)))
 		
 		// Send file directly
@@ -100,10 +124,58 @@ func uploadHandlerLarge(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, ConvertResponse{
-		Success: true,
-		Data:    result,
-	})
+	response := ConvertResponse{
+		Success:  true,
+		Data:     result,
+		Warnings: *warnings,
+	}
+	if stats.Compression != "" {
+		response.Compression = stats.Compression
+		response.CompressedSize = stats.CompressedBytes
+		response.UncompressedSize = stats.UncompressedBytes
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows a gzip response.
+// An explicit gzip entry takes precedence over "*", and q=0 refuses a coding.
+func acceptsGzip(acceptEncoding string) bool {
+	gzipQ, anyQ := -1.0, -1.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		params := strings.Split(part, ";")
+		q := 1.0
+		for _, param := range params[1:] {
+			name, value, _ := strings.Cut(param, "=")
+			if strings.EqualFold(strings.TrimSpace(name), "q") {
+				if q, _ = strconv.ParseFloat(strings.TrimSpace(value), 64); q < 0 {
+					q = 0
+				}
+			}
+		}
+		switch strings.ToLower(strings.TrimSpace(params[0])) {
+		case "gzip", "x-gzip":
+			gzipQ = q
+		case "*":
+			anyQ = q
+		}
+	}
+	if gzipQ >= 0 {
+		return gzipQ > 0
+	}
+	return anyQ > 0
+}
+
+// writeGzip writes data to w as a gzip stream
+func writeGzip(w io.Writer, data []byte) error {
+	gz, err := converter.NewCompressedWriter(w, "gzip")
+	if err != nil {
+		return err
+	}
+	if _, err := gz.Write(data); err != nil {
+		gz.Close()
+		return err
+	}
+	return gz.Close()
 }
 
 // downloadHandler serves large converted files for download
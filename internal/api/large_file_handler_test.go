@@ -0,0 +1,63 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+)
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected bool
+	}{
+		{"", false},
+		{"gzip", true},
+		{"GZIP", true},
+		{"deflate, br", false},
+		{"deflate, gzip;q=0.5", true},
+		{"gzip;q=0", false},
+		{"gzip; Q=0", false},
+		{"gzip;q=0.000", false},
+		{"gzip;q=0.001", true},
+		{"*", true},
+		{"*;q=0", false},
+		{"*;q=0, gzip", true},
+		{"gzip;q=0, *", false},
+		{"x-gzip", true},
+		{"identity;q=1, *;q=0", false},
+	}
+
+	for _, tt := range tests {
+		if got := acceptsGzip(tt.header); got != tt.expected {
+			t.Errorf("acceptsGzip(%q) = %v, want %v", tt.header, got, tt.expected)
+		}
+	}
+}
+
+func TestWriteGzip(t *testing.T) {
+	data := []byte(`[{"id":1},{"id":2}]`)
+
+	var buf bytes.Buffer
+	if err := writeGzip(&buf, data); err != nil {
+		t.Fatalf("writeGzip() error = %v", err)
+	}
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+	got, err := io.ReadAll(gz)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Errorf("round trip = %q, %v; want %q", got, err, data)
+	}
+
+	if err := writeGzip(failingWriter{}, data); err == nil {
+		t.Error("writeGzip() should report a failed write")
+	}
+}
+
+// failingWriter rejects every write, like a client that went away
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, io.ErrClosedPipe }
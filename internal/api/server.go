@@ -1,9 +1,14 @@
 package api
 
 import (
+	"compress/gzip"
 	"csv2json/internal/converter"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"os"
 	"strconv"
 	"strings"
 
@@ -24,17 +29,29 @@ type ConvertResponse struct {
 	Message     string      `json:"message,omitempty"`
 	DownloadURL string      `json:"download_url,omitempty"`
 	FileSize    int         `json:"file_size,omitempty"`
+
+	Compression      string `json:"compression,omitempty"`
+	CompressedSize   int64  `json:"compressed_size,omitempty"`
+	UncompressedSize int64  `json:"uncompressed_size,omitempty"`
+
+	Warnings []string `json:"warnings,omitempty"`
 }
 
 // StartServer initializes and starts the API server
 func StartServer() {
 	r := gin.Default()
 
+	if limit := os.Getenv("CSV2JSON_MAX_DECOMPRESSED_SIZE"); limit != "" {
+		if size, err := strconv.ParseInt(limit, 10, 64); err == nil && size > 0 {
+			MaxDecompressedSize = size
+		}
+	}
+
 	// Enable CORS
 	r.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Content-Encoding")
 		
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -44,6 +61,9 @@ func StartServer() {
 		c.Next()
 	})
 
+	// Accept gzip-compressed request bodies
+	r.Use(decompressRequestBody)
+
 	// Health check endpoint
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
@@ -58,6 +78,9 @@ func StartServer() {
 	// File upload endpoint
 	r.POST("/upload", uploadHandlerLarge)
 
+	// Convert JSON or NDJSON back to CSV
+	r.POST("/tocsv", tocsvHandler)
+
 	// Download endpoint for large files
 	r.GET("/download/:filename", downloadHandler)
 
@@ -75,6 +98,9 @@ func StartServer() {
 func convertHandler(c *gin.Context) {
 	var req ConvertRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
+		if abortTooLarge(c, err) {
+			return
+		}
 		c.JSON(http.StatusBadRequest, ConvertResponse{
 			Success: false,
 			Error:   "Invalid request format: " + err.Error(),
@@ -86,6 +112,7 @@ func convertHandler(c *gin.Context) {
 	if req.Options.Delimiter == 0 {
 		req.Options = converter.DefaultOptions()
 	}
+	warnings := collectWarnings(&req.Options)
 
 	// Convert CSV to JSON
 	reader := strings.NewReader(req.CSVData)
@@ -109,8 +136,9 @@ func convertHandler(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, ConvertResponse{
-		Success: true,
-		Data:    result,
+		Success:  true,
+		Data:     result,
+		Warnings: *warnings,
 	})
 }
 
@@ -127,58 +155,311 @@ func uploadHandler(c *gin.Context) {
 	defer file.Close()
 
 	// Parse options from form data
+	options, err := formOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ConvertResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+	warnings := collectWarnings(&options)
+
+	// Convert CSV to JSON
+	jsonData, err := converter.ConvertCSVToJSON(file, options)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ConvertResponse{
+			Success: false,
+			Error:   "Conversion failed: " + err.Error(),
+		})
+		return
+	}
+
+	// Parse JSON data to return as proper JSON response
+	var result interface{}
+	if err := json.Unmarshal(jsonData, &result); err != nil {
+		c.JSON(http.StatusInternalServerError, ConvertResponse{
+			Success: false,
+			Error:   "Failed to parse converted JSON: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, ConvertResponse{
+		Success:  true,
+		Data:     result,
+		Warnings: *warnings,
+	})
+}
+
+// MaxDecompressedSize caps how far a compressed request body or upload may
+// expand, so that a small upload cannot fill memory. StartServer reads an
+// override in bytes from CSV2JSON_MAX_DECOMPRESSED_SIZE.
+var MaxDecompressedSize int64 = 200 << 20
+
+// errDecompressedTooLarge is returned by reads past MaxDecompressedSize
+var errDecompressedTooLarge = errors.New("decompressed input is too large")
+
+// limitedReader fails with errDecompressedTooLarge once more than its limit
+// has been read, where io.LimitReader would silently truncate the input
+type limitedReader struct {
+	reader    io.Reader
+	remaining int64
+}
+
+func limitDecompressed(reader io.Reader) *limitedReader {
+	return &limitedReader{reader: reader, remaining: MaxDecompressedSize}
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		// Input that ends exactly at the limit is still accepted
+		var probe [1]byte
+		if n, err := l.reader.Read(probe[:]); n == 0 {
+			return 0, err
+		}
+		return 0, errDecompressedTooLarge
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.reader.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
+
+// abortTooLarge answers 413 Request Entity Too Large when err comes from
+// reading past MaxDecompressedSize, and reports whether it did
+func abortTooLarge(c *gin.Context, err error) bool {
+	if !errors.Is(err, errDecompressedTooLarge) {
+		return false
+	}
+	c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, ConvertResponse{
+		Success: false,
+		Error:   fmt.Sprintf("Decompressed input exceeds the limit of %d bytes", MaxDecompressedSize),
+	})
+	return true
+}
+
+// gzipBody reads the size-limited gzip stream and closes both the gzip reader
+// and the underlying request body
+type gzipBody struct {
+	io.Reader
+	gz   *gzip.Reader
+	body io.ReadCloser
+}
+
+func (g gzipBody) Close() error {
+	g.gz.Close()
+	return g.body.Close()
+}
+
+// decompressRequestBody transparently decodes requests sent with Content-Encoding: gzip
+func decompressRequestBody(c *gin.Context) {
+	if !strings.EqualFold(c.GetHeader("Content-Encoding"), "gzip") {
+		c.Next()
+		return
+	}
+
+	gz, err := gzip.NewReader(c.Request.Body)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, ConvertResponse{
+			Success: false,
+			Error:   "Invalid gzip request body: " + err.Error(),
+		})
+		return
+	}
+
+	c.Request.Body = gzipBody{Reader: limitDecompressed(gz), gz: gz, body: c.Request.Body}
+	c.Request.Header.Del("Content-Encoding")
+	c.Request.Header.Del("Content-Length")
+	c.Request.ContentLength = -1
+	c.Next()
+}
+
+// collectWarnings records the warnings raised while converting with options
+func collectWarnings(options *converter.ConversionOptions) *[]string {
+	warnings := &[]string{}
+	options.OnWarning = func(message string) {
+		*warnings = append(*warnings, message)
+	}
+	return warnings
+}
+
+// formOptions builds conversion options from multipart form fields
+func formOptions(c *gin.Context) (converter.ConversionOptions, error) {
 	options := converter.DefaultOptions()
-	
+
 	if delimiter := c.PostForm("delimiter"); delimiter != "" {
-		if len(delimiter) == 1 {
-			options.Delimiter = rune(delimiter[0])
+		if err := options.SetDelimiter(delimiter); err != nil {
+			return options, fmt.Errorf("Invalid delimiter: %w", err)
 		}
 	}
-	
+
 	if hasHeader := c.PostForm("has_header"); hasHeader != "" {
 		if val, err := strconv.ParseBool(hasHeader); err == nil {
 			options.HasHeader = val
 		}
 	}
-	
+
 	if outputFormat := c.PostForm("output_format"); outputFormat != "" {
 		options.OutputFormat = outputFormat
 	}
-	
+
 	if prettyPrint := c.PostForm("pretty_print"); prettyPrint != "" {
 		if val, err := strconv.ParseBool(prettyPrint); err == nil {
 			options.PrettyPrint = val
 		}
 	}
-	
+
 	if inferTypes := c.PostForm("infer_types"); inferTypes != "" {
 		if val, err := strconv.ParseBool(inferTypes); err == nil {
 			options.InferTypes = val
 		}
 	}
 
-	// Convert CSV to JSON
-	jsonData, err := converter.ConvertCSVToJSON(file, options)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, ConvertResponse{
-			Success: false,
-			Error:   "Conversion failed: " + err.Error(),
-		})
-		return
+	if inputFormat := c.PostForm("input_format"); inputFormat != "" {
+		options.InputFormat = inputFormat
 	}
 
-	// Parse JSON data to return as proper JSON response
-	var result interface{}
-	if err := json.Unmarshal(jsonData, &result); err != nil {
-		c.JSON(http.StatusInternalServerError, ConvertResponse{
-			Success: false,
-			Error:   "Failed to parse converted JSON: " + err.Error(),
-		})
-		return
+	if sheet := c.PostForm("sheet"); sheet != "" {
+		options.Sheet = sheet
 	}
 
-	c.JSON(http.StatusOK, ConvertResponse{
-		Success: true,
-		Data:    result,
-	})
+	if headers := c.PostForm("headers"); headers != "" {
+		options.Headers = converter.ParseHeaderList(headers)
+	}
+
+	if columnNames := c.PostForm("column_names"); columnNames != "" {
+		options.ColumnNames = columnNames
+	}
+
+	if comment := c.PostForm("comment"); comment != "" {
+		options.Comment = comment
+	}
+
+	var err error
+	if options.SkipRows, err = formCount(c, "skip_rows"); err != nil {
+		return options, err
+	}
+	if options.HeaderRow, err = formCount(c, "header_row"); err != nil {
+		return options, err
+	}
+	if options.SkipFooter, err = formCount(c, "skip_footer"); err != nil {
+		return options, err
+	}
+	if options.HeaderRows, err = formCount(c, "header_rows"); err != nil {
+		return options, err
+	}
+
+	if layout := c.PostForm("layout"); layout != "" {
+		options.Layout = layout
+	}
+
+	if key := c.PostForm("key"); key != "" {
+		options.Key = key
+	}
+
+	if duplicateKeys := c.PostForm("duplicate_keys"); duplicateKeys != "" {
+		options.DuplicateKeys = duplicateKeys
+	}
+
+	if dropKey := c.PostForm("drop_key"); dropKey != "" {
+		if val, err := strconv.ParseBool(dropKey); err == nil {
+			options.DropKey = val
+		}
+	}
+
+	if unpivot, ok := c.GetPostForm("unpivot"); ok {
+		options.Reshape = "unpivot"
+		options.IDColumns = converter.ParseHeaderList(strings.TrimPrefix(strings.TrimSpace(unpivot), "id_cols="))
+	}
+
+	if pivot := c.PostForm("pivot"); pivot != "" {
+		if val, err := strconv.ParseBool(pivot); err == nil && val {
+			if options.Reshape != "" {
+				return options, fmt.Errorf("unpivot and pivot cannot be combined")
+			}
+			options.Reshape = "pivot"
+		}
+	}
+
+	options.VarName = c.PostForm("var_name")
+	options.ValueName = c.PostForm("value_name")
+	options.PivotAggregate = c.PostForm("pivot_agg")
+
+	if groupBy := c.PostForm("group_by"); groupBy != "" {
+		options.GroupBy = converter.ParseHeaderList(groupBy)
+	}
+
+	for _, spec := range c.PostFormArray("nest") {
+		nest, err := converter.ParseNestSpec(spec)
+		if err != nil {
+			return options, fmt.Errorf("Invalid nest: %w", err)
+		}
+		options.Nest = append(options.Nest, nest)
+	}
+
+	if aggregate := c.PostForm("aggregate"); aggregate != "" {
+		spec, err := converter.ParseAggregateSpec(aggregate)
+		if err != nil {
+			return options, fmt.Errorf("Invalid aggregate: %w", err)
+		}
+		options.Aggregate = &spec
+	}
+
+	if groupSorted := c.PostForm("group_sorted"); groupSorted != "" {
+		if val, err := strconv.ParseBool(groupSorted); err == nil {
+			options.GroupSorted = val
+		}
+	}
+
+	if multiTable := c.PostForm("multi_table"); multiTable != "" {
+		if val, err := strconv.ParseBool(multiTable); err == nil {
+			options.MultiTable = val
+		}
+	}
+
+	if tableMarker := c.PostForm("table_marker"); tableMarker != "" {
+		options.TableMarker = tableMarker
+		options.MultiTable = true
+	}
+
+	if tableTitles := c.PostForm("table_titles"); tableTitles != "" {
+		if val, err := strconv.ParseBool(tableTitles); err == nil {
+			options.TableTitles = val
+		}
+	}
+
+	if detectFormulas := c.PostForm("detect_formulas"); detectFormulas != "" {
+		if val, err := strconv.ParseBool(detectFormulas); err == nil {
+			options.DetectFormulas = val
+		}
+	}
+
+	if widths := c.PostForm("widths"); widths != "" {
+		columns, err := converter.ParseFixedWidthSpec(widths)
+		if err != nil {
+			return options, fmt.Errorf("Invalid widths: %w", err)
+		}
+		options.FixedWidths = columns
+		if options.InputFormat == "" || options.InputFormat == "csv" {
+			options.InputFormat = "fixed"
+		}
+	}
+
+	return options, nil
+}
+
+// formCount parses an optional non-negative integer form field
+func formCount(c *gin.Context, field string) (int, error) {
+	value := c.PostForm(field)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("Invalid %s: %q", field, value)
+	}
+	return n, nil
 }
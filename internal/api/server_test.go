@@ -0,0 +1,97 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func gzipBytes(t *testing.T, data string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write([]byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecompressedSizeLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(decompressRequestBody)
+	r.POST("/convert", convertHandler)
+	r.POST("/upload", uploadHandlerLarge)
+	r.POST("/tocsv", tocsvHandler)
+
+	defer func(limit int64) { MaxDecompressedSize = limit }(MaxDecompressedSize)
+	MaxDecompressedSize = 1000
+
+	smallCSV := "id\n1\n"
+	largeCSV := "id\n" + strings.Repeat("1\n", 5000)
+	jsonBody := func(csvData string) string {
+		body, _ := json.Marshal(map[string]string{"csv_data": csvData})
+		return string(body)
+	}
+	upload := func(name string, data []byte) (*bytes.Buffer, string) {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, _ := form.CreateFormFile("file", name)
+		part.Write(data)
+		form.Close()
+		return &body, form.FormDataContentType()
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		body     func() (*bytes.Buffer, string)
+		encoding string
+		status   int
+	}{
+		{"Small gzip body", "/convert", func() (*bytes.Buffer, string) {
+			return bytes.NewBuffer(gzipBytes(t, jsonBody(smallCSV))), "application/json"
+		}, "gzip", http.StatusOK},
+		{"Large gzip body", "/convert", func() (*bytes.Buffer, string) {
+			return bytes.NewBuffer(gzipBytes(t, jsonBody(largeCSV))), "application/json"
+		}, "gzip", http.StatusRequestEntityTooLarge},
+		{"Small gzip upload", "/upload", func() (*bytes.Buffer, string) {
+			return upload("data.csv.gz", gzipBytes(t, smallCSV))
+		}, "", http.StatusOK},
+		{"Large gzip upload", "/upload", func() (*bytes.Buffer, string) {
+			return upload("data.csv.gz", gzipBytes(t, largeCSV))
+		}, "", http.StatusRequestEntityTooLarge},
+		{"Large plain upload", "/upload", func() (*bytes.Buffer, string) {
+			return upload("data.csv", []byte(largeCSV))
+		}, "", http.StatusOK},
+		{"Large gzip JSON for tocsv", "/tocsv", func() (*bytes.Buffer, string) {
+			return bytes.NewBuffer(gzipBytes(t, "["+strings.Repeat(`{"id":1},`, 500)+`{"id":1}]`)), "application/json"
+		}, "", http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, contentType := tt.body()
+			req := httptest.NewRequest(http.MethodPost, tt.path, body)
+			req.Header.Set("Content-Type", contentType)
+			if tt.encoding != "" {
+				req.Header.Set("Content-Encoding", tt.encoding)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"bytes"
+	"csv2json/internal/converter"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tocsvHandler converts an uploaded JSON array, column-oriented object or NDJSON
+// document to CSV. The JSON is read from the "file" form field when present,
+// otherwise from the raw request body.
+func tocsvHandler(c *gin.Context) {
+	var input io.Reader = c.Request.Body
+	if file, _, err := c.Request.FormFile("file"); err == nil {
+		defer file.Close()
+		input = file
+	} else if abortTooLarge(c, err) {
+		return
+	}
+
+	options, err := tocsvOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ConvertResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	input, stats, err := converter.Decompress(input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ConvertResponse{
+			Success: false,
+			Error:   "Failed to read input: " + err.Error(),
+		})
+		return
+	}
+	if stats.Compression != "" {
+		input = limitDecompressed(input)
+	}
+
+	var buf bytes.Buffer
+	if err := converter.ConvertJSONToCSV(input, &buf, options); err != nil {
+		if abortTooLarge(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, ConvertResponse{
+			Success: false,
+			Error:   "Conversion failed: " + err.Error(),
+		})
+		return
+	}
+
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+// tocsvOptions builds JSON to CSV options from query or form fields
+func tocsvOptions(c *gin.Context) (converter.JSONToCSVOptions, error) {
+	options := converter.DefaultJSONToCSVOptions()
+
+	if delimiter := param(c, "delimiter"); delimiter != "" {
+		var parsed converter.ConversionOptions
+		if err := parsed.SetDelimiter(delimiter); err != nil || parsed.Delimiter == 0 {
+			return options, fmt.Errorf("Invalid delimiter: CSV output needs a single character")
+		}
+		options.Delimiter = parsed.Delimiter
+	}
+
+	if joiner, ok := paramOK(c, "joiner"); ok {
+		options.ArrayJoiner = joiner
+	}
+
+	if separator := param(c, "key_separator"); separator != "" {
+		options.KeySeparator = separator
+	}
+
+	if crlf := param(c, "crlf"); crlf != "" {
+		if val, err := strconv.ParseBool(crlf); err == nil {
+			options.UseCRLF = val
+		}
+	}
+
+	if sanitize := param(c, "sanitize_formulas"); sanitize != "" {
+		if val, err := strconv.ParseBool(sanitize); err == nil {
+			options.SanitizeFormulas = val
+		}
+	}
+
+	return options, nil
+}
+
+// param returns a query parameter, falling back to a form field
+func param(c *gin.Context, key string) string {
+	value, _ := paramOK(c, key)
+	return value
+}
+
+// paramOK is like param but also reports whether the key was present
+func paramOK(c *gin.Context, key string) (string, bool) {
+	if value, ok := c.GetQuery(key); ok {
+		return value, true
+	}
+	if c.ContentType() == "multipart/form-data" || c.ContentType() == "application/x-www-form-urlencoded" {
+		return c.GetPostForm(key)
+	}
+	return "", false
+}
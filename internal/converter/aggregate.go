@@ -0,0 +1,287 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// aggregateFunctions are the functions accepted wherever values are combined
+var aggregateFunctions = map[string]bool{
+	"count": true, "sum": true, "avg": true, "min": true, "max": true, "first": true, "last": true,
+}
+
+// aggregator combines the typed values produced by parseValueUltra. Null
+// values are ignored; sum and avg also ignore values that are not numbers.
+// min and max compare numbers numerically and anything else as text, which
+// orders ISO dates correctly.
+type aggregator struct {
+	function string
+	count    int
+	numbers  int
+	sum      float64
+	intSum   int64
+	integer  bool // every number summed so far was an integer, so intSum is exact
+	value    interface{}
+}
+
+func newAggregator(function string) (*aggregator, error) {
+	if !aggregateFunctions[function] {
+		return nil, fmt.Errorf("unknown aggregate function %q: use count, sum, avg, min, max, first or last", function)
+	}
+	return &aggregator{function: function, integer: true}, nil
+}
+
+func (a *aggregator) add(value interface{}) {
+	if value == nil {
+		return
+	}
+	a.count++
+
+	switch a.function {
+	case "sum", "avg":
+		if n, ok := toNumber(value); ok {
+			a.numbers++
+			a.sum += n
+			i, isInt := value.(int64)
+			a.intSum += i
+			a.integer = a.integer && isInt
+		}
+	case "min":
+		if a.count == 1 || compareValues(value, a.value) < 0 {
+			a.value = value
+		}
+	case "max":
+		if a.count == 1 || compareValues(value, a.value) > 0 {
+			a.value = value
+		}
+	case "first":
+		if a.count == 1 {
+			a.value = value
+		}
+	case "last":
+		a.value = value
+	}
+}
+
+func (a *aggregator) result() interface{} {
+	switch a.function {
+	case "count":
+		return int64(a.count)
+	case "sum":
+		if a.integer {
+			return a.intSum
+		}
+		return a.sum
+	case "avg":
+		if a.numbers == 0 {
+			return nil
+		}
+		return a.sum / float64(a.numbers)
+	}
+	return a.value
+}
+
+// toNumber returns the numeric value of a parsed cell, parsing text when type
+// inference is off
+func toNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case string:
+		n, err := strconv.ParseFloat(v, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// compareValues orders two non-null values, numerically when both are numbers
+func compareValues(a, b interface{}) int {
+	x, xNumber := toNumber(a)
+	y, yNumber := toNumber(b)
+	if xNumber && yNumber {
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	}
+
+	s, t := fmt.Sprint(a), fmt.Sprint(b)
+	switch {
+	case s < t:
+		return -1
+	case s > t:
+		return 1
+	}
+	return 0
+}
+
+// AggregateSpec describes a summary: the group-by columns and the aggregate
+// columns computed for each group
+type AggregateSpec struct {
+	GroupBy []string
+	Columns []AggregateColumn
+}
+
+// AggregateColumn is one function applied to a field; an empty field with
+// count counts rows
+type AggregateColumn struct {
+	Function string
+	Field    string
+	Name     string // output key, e.g. "count" or "sum_salary"
+}
+
+// ParseAggregateSpec parses a summary such as
+// "department: count, sum(salary), avg(age), max(join_date)". The group-by
+// columns before the colon are optional and may be written "group by department".
+func ParseAggregateSpec(spec string) (AggregateSpec, error) {
+	var result AggregateSpec
+
+	columns := spec
+	if groups, rest, ok := strings.Cut(spec, ":"); ok {
+		groups = strings.TrimSpace(groups)
+		if len(groups) >= 9 && strings.EqualFold(groups[:9], "group by ") {
+			groups = groups[9:]
+		}
+		result.GroupBy = ParseHeaderList(groups)
+		columns = rest
+	}
+
+	for _, item := range strings.Split(columns, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		function, field := strings.ToLower(item), ""
+		if open := strings.Index(item, "("); open >= 0 {
+			if !strings.HasSuffix(item, ")") {
+				return result, fmt.Errorf("invalid aggregate %q: missing closing parenthesis", item)
+			}
+			function = strings.ToLower(strings.TrimSpace(item[:open]))
+			field = strings.TrimSpace(item[open+1 : len(item)-1])
+		}
+		if field == "*" {
+			field = ""
+		}
+		if _, err := newAggregator(function); err != nil {
+			return result, err
+		}
+		if field == "" && function != "count" {
+			return result, fmt.Errorf("invalid aggregate %q: %s needs a column", item, function)
+		}
+
+		name := function
+		if field != "" {
+			name = function + "_" + field
+		}
+		result.Columns = append(result.Columns, AggregateColumn{Function: function, Field: field, Name: name})
+	}
+
+	if len(result.Columns) == 0 {
+		return result, fmt.Errorf("invalid aggregate %q: no aggregate functions given", spec)
+	}
+	return result, nil
+}
+
+// summarizer replaces the records with one summary record per group, in
+// first-seen group order, holding the group-by columns and the aggregates
+type summarizer struct {
+	next   recordWriter
+	spec   AggregateSpec
+	order  []string
+	groups map[string]*summaryGroup
+}
+
+// summaryGroup accumulates the aggregates of one group
+type summaryGroup struct {
+	keys        []interface{}
+	aggregators []*aggregator
+}
+
+// newSummarizer returns a summarizer for spec, whose group-by columns and
+// fields must be among the headers when they are known
+func newSummarizer(next recordWriter, headers []string, spec AggregateSpec) (*summarizer, error) {
+	if err := checkColumns(headers, "aggregate group-by", spec.GroupBy...); err != nil {
+		return nil, err
+	}
+	for _, column := range spec.Columns {
+		if column.Field == "" {
+			continue
+		}
+		if err := checkColumns(headers, "aggregate", column.Field); err != nil {
+			return nil, err
+		}
+	}
+	return &summarizer{next: next, spec: spec, groups: make(map[string]*summaryGroup)}, nil
+}
+
+// groupFor returns the group of a record, starting a new one when needed
+func (s *summarizer) groupFor(record map[string]interface{}) (*summaryGroup, error) {
+	keys := make([]interface{}, len(s.spec.GroupBy))
+	for i, column := range s.spec.GroupBy {
+		keys[i] = record[column]
+	}
+	keyJSON, err := json.Marshal(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	group, ok := s.groups[string(keyJSON)]
+	if !ok {
+		group = &summaryGroup{keys: keys}
+		for _, column := range s.spec.Columns {
+			a, _ := newAggregator(column.Function)
+			group.aggregators = append(group.aggregators, a)
+		}
+		s.groups[string(keyJSON)] = group
+		s.order = append(s.order, string(keyJSON))
+	}
+	return group, nil
+}
+
+func (s *summarizer) write(record map[string]interface{}) error {
+	group, err := s.groupFor(record)
+	if err != nil {
+		return err
+	}
+
+	for i, column := range s.spec.Columns {
+		if column.Field == "" {
+			// count(*) counts rows, whatever their values
+			group.aggregators[i].add(true)
+			continue
+		}
+		group.aggregators[i].add(record[column.Field])
+	}
+	return nil
+}
+
+func (s *summarizer) close() error {
+	if len(s.spec.GroupBy) == 0 && len(s.order) == 0 {
+		// An ungrouped summary of no rows still reports count 0
+		if _, err := s.groupFor(nil); err != nil {
+			return err
+		}
+	}
+	for _, key := range s.order {
+		group := s.groups[key]
+		summary := make(map[string]interface{}, len(s.spec.GroupBy)+len(s.spec.Columns))
+		for i, column := range s.spec.GroupBy {
+			summary[column] = group.keys[i]
+		}
+		for i, column := range s.spec.Columns {
+			summary[column.Name] = group.aggregators[i].result()
+		}
+		if err := s.next.write(summary); err != nil {
+			return err
+		}
+	}
+	return s.next.close()
+}
@@ -0,0 +1,128 @@
+package converter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParseAggregateSpec(t *testing.T) {
+	spec, err := ParseAggregateSpec("group by department, city: count, SUM(salary), avg(age), max(join_date), count(email)")
+	if err != nil {
+		t.Fatalf("ParseAggregateSpec failed: %v", err)
+	}
+	if strings.Join(spec.GroupBy, "|") != "department|city" {
+		t.Errorf("unexpected group-by columns: %q", spec.GroupBy)
+	}
+
+	var names []string
+	for _, column := range spec.Columns {
+		names = append(names, column.Name)
+	}
+	if got := strings.Join(names, "|"); got != "count|sum_salary|avg_age|max_join_date|count_email" {
+		t.Errorf("unexpected aggregate names: %s", got)
+	}
+
+	for _, invalid := range []string{"department:", "median(age)", "sum", "sum(age"} {
+		if _, err := ParseAggregateSpec(invalid); err == nil {
+			t.Errorf("expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestAggregateOutput(t *testing.T) {
+	input := "id,name,age,salary,department,join_date\n" +
+		"1,Ann,30,50000,Sales,2020-03-01\n" +
+		"2,Bob,40,70000,IT,2020-01-15\n" +
+		"3,Cid,,60000,Sales,2020-07-09\n"
+
+	tests := []struct {
+		spec     string
+		expected string
+	}{
+		{
+			spec: "department: count, sum(salary), avg(age), min(join_date), max(join_date)",
+			expected: `[{"avg_age":30,"count":2,"department":"Sales","max_join_date":"2020-07-09","min_join_date":"2020-03-01","sum_salary":110000},` +
+				`{"avg_age":40,"count":1,"department":"IT","max_join_date":"2020-01-15","min_join_date":"2020-01-15","sum_salary":70000}]`,
+		},
+		{
+			spec:     "count, count(age), avg(salary)",
+			expected: `[{"avg_salary":60000,"count":3,"count_age":2}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			spec, err := ParseAggregateSpec(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseAggregateSpec failed: %v", err)
+			}
+			options := DefaultOptions()
+			options.PrettyPrint = false
+			options.Aggregate = &spec
+
+			result, err := ConvertCSVToJSON(strings.NewReader(input), options)
+			if err != nil {
+				t.Fatalf("ConvertCSVToJSON failed: %v", err)
+			}
+			if string(result) != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestAggregateWithoutRows(t *testing.T) {
+	spec, _ := ParseAggregateSpec("count, sum(x)")
+	options := DefaultOptions()
+	options.PrettyPrint = false
+	options.Aggregate = &spec
+
+	// A header-only and a completely empty input both report a zero count,
+	// whether converted in memory or streamed
+	for _, input := range []string{"x\n", ""} {
+		result, err := ConvertCSVToJSON(strings.NewReader(input), options)
+		if err != nil {
+			t.Fatalf("ConvertCSVToJSON failed: %v", err)
+		}
+		var streamed bytes.Buffer
+		if err := ConvertCSVToJSONStream(strings.NewReader(input), &streamed, options); err != nil {
+			t.Fatalf("ConvertCSVToJSONStream failed: %v", err)
+		}
+		if expected := `[{"count":0,"sum_x":0}]`; string(result) != expected || streamed.String() != expected {
+			t.Errorf("input %q: expected %s, got %s and streamed %s", input, expected, result, streamed.String())
+		}
+	}
+}
+
+func TestAggregateUnknownColumns(t *testing.T) {
+	input := "department,salary\nsales,10\n"
+
+	tests := []struct {
+		spec string
+		err  string
+	}{
+		{"nope: sum(salary)", `unknown aggregate group-by column "nope"`},
+		{"department: sum(zzz)", `unknown aggregate column "zzz"`},
+		{"count, max(salry)", `unknown aggregate column "salry"`},
+	}
+
+	for _, tt := range tests {
+		spec, err := ParseAggregateSpec(tt.spec)
+		if err != nil {
+			t.Fatalf("ParseAggregateSpec(%q) failed: %v", tt.spec, err)
+		}
+		options := DefaultOptions()
+		options.Aggregate = &spec
+
+		_, err = ConvertCSVToJSON(strings.NewReader(input), options)
+		var streamed bytes.Buffer
+		streamErr := ConvertCSVToJSONStream(strings.NewReader(input), &streamed, options)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.spec, tt.err, err)
+		}
+		if streamErr == nil || !strings.Contains(streamErr.Error(), tt.err) {
+			t.Errorf("%s stream: expected error containing %q, got %v", tt.spec, tt.err, streamErr)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package converter
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// InputStats describes the compression detected on an input. The byte counts
+// are updated as the input is read and are final once conversion completes.
+type InputStats struct {
+	Compression       string // "gzip", "bzip2" or "" for plain input
+	CompressedBytes   int64
+	UncompressedBytes int64
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	reader io.Reader
+	count  *int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+	*c.count += int64(n)
+	return n, err
+}
+
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	bzip2Magic = []byte("BZh")
+)
+
+// Decompress inspects the magic bytes at the start of reader and transparently
+// wraps it in a gzip or bzip2 decoder. Plain input is returned unchanged apart
+// from byte counting.
+func Decompress(reader io.Reader) (io.Reader, *InputStats, error) {
+	stats := &InputStats{}
+	buffered := bufio.NewReader(&countingReader{reader: reader, count: &stats.CompressedBytes})
+
+	magic, _ := buffered.Peek(4)
+	var decoded io.Reader
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, nil, err
+		}
+		stats.Compression = "gzip"
+		decoded = gz
+	case bytes.HasPrefix(magic, bzip2Magic) && len(magic) == 4 && magic[3] >= '1' && magic[3] <= '9':
+		stats.Compression = "bzip2"
+		decoded = bzip2.NewReader(buffered)
+	default:
+		decoded = buffered
+	}
+
+	return &countingReader{reader: decoded, count: &stats.UncompressedBytes}, stats, nil
+}
+
+// nopWriteCloser adds a no-op Close to an io.Writer
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// NewCompressedWriter wraps w in the named compressor. Compression may be
+// "gzip" or "" / "none" for plain output. Closing the returned writer flushes
+// the compressor but does not close w.
+func NewCompressedWriter(w io.Writer, compression string) (io.WriteCloser, error) {
+	switch compression {
+	case "", "none":
+		return nopWriteCloser{w}, nil
+	case "gzip":
+		return gzip.NewWriter(w), nil
+	}
+	return nil, fmt.Errorf("unsupported output compression %q", compression)
+}
+
+// CompressionForPath returns the output compression implied by a file name suffix
+func CompressionForPath(path string) string {
+	if strings.HasSuffix(strings.ToLower(path), ".gz") {
+		return "gzip"
+	}
+	return ""
+}
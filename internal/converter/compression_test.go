@@ -0,0 +1,77 @@
+package converter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+)
+
+func TestDecompress(t *testing.T) {
+	const csvData = "a,b\n1,2\n"
+
+	var gzipped bytes.Buffer
+	gz := gzip.NewWriter(&gzipped)
+	gz.Write([]byte(csvData))
+	gz.Close()
+
+	// printf 'a,b\n1,2\n' | bzip2 -c
+	bzipped := []byte{
+		0x42, 0x5a, 0x68, 0x39, 0x31, 0x41, 0x59, 0x26, 0x53, 0x59, 0xbf, 0x87,
+		0x40, 0x7f, 0x00, 0x00, 0x03, 0x59, 0x00, 0x00, 0x10, 0x00, 0x04, 0x30,
+		0x00, 0x30, 0x00, 0x20, 0x00, 0x30, 0xc0, 0x08, 0x69, 0xb2, 0x88, 0x23,
+		0x27, 0x8b, 0xb9, 0x22, 0x9c, 0x28, 0x48, 0x5f, 0xc3, 0xa0, 0x3f, 0x80,
+	}
+
+	tests := []struct {
+		name        string
+		input       []byte
+		compression string
+	}{
+		{name: "plain", input: []byte(csvData), compression: ""},
+		{name: "gzip", input: gzipped.Bytes(), compression: "gzip"},
+		{name: "bzip2", input: bzipped, compression: "bzip2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, stats, err := Decompress(bytes.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("Decompress() error = %v", err)
+			}
+			data, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("ReadAll() error = %v", err)
+			}
+
+			if string(data) != csvData {
+				t.Errorf("Decompress() data = %q, want %q", data, csvData)
+			}
+			if stats.Compression != tt.compression {
+				t.Errorf("Compression = %q, want %q", stats.Compression, tt.compression)
+			}
+			if stats.CompressedBytes != int64(len(tt.input)) || stats.UncompressedBytes != int64(len(csvData)) {
+				t.Errorf("stats = %+v, want %d compressed and %d uncompressed bytes", stats, len(tt.input), len(csvData))
+			}
+		})
+	}
+}
+
+func TestCompressionForPath(t *testing.T) {
+	tests := map[string]string{
+		"out.json":     "",
+		"out.json.gz":  "gzip",
+		"OUT.JSON.GZ":  "gzip",
+		"out.json.bz2": "",
+		"gz":           "",
+	}
+	for path, expected := range tests {
+		if got := CompressionForPath(path); got != expected {
+			t.Errorf("CompressionForPath(%q) = %q, want %q", path, got, expected)
+		}
+	}
+
+	if _, err := NewCompressedWriter(io.Discard, "zstd"); err == nil {
+		t.Error("NewCompressedWriter() expected an error for an unsupported compression")
+	}
+}
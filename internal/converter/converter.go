@@ -1,17 +1,137 @@
 package converter
 
 import (
+	"fmt"
 	"io"
 	"runtime"
+	"strings"
 )
 
 // ConversionOptions holds configuration for CSV to JSON conversion
 type ConversionOptions struct {
 	Delimiter    rune
 	HasHeader    bool
-	OutputFormat string // "array" or "object"
+	OutputFormat string // "array", "object", "ndjson" or "keyed" (also "index")
 	PrettyPrint  bool
 	InferTypes   bool
+
+	// Dialect selects a named input dialect: "csv", "mysql" or "postgres-text"
+	Dialect string
+	// Quote and Escape override the dialect's quote and escape characters
+	Quote  rune
+	Escape rune
+
+	// Separator is a multi-character delimiter such as "||" and overrides Delimiter
+	Separator string
+	// SeparatorPattern is a regular expression matched between fields
+	SeparatorPattern string
+	// SplitWhitespace splits fields on runs of spaces and tabs
+	SplitWhitespace bool
+
+	// InputFormat is "csv" (the default), "fixed" or "xlsx"
+	InputFormat string
+	// FixedWidths describes the columns of fixed-width input, see ParseFixedWidthSpec
+	FixedWidths []FixedWidthColumn
+	// Sheet selects an xlsx worksheet by name or 1-based index (default: first sheet)
+	Sheet string
+
+	// Headers supplies the column names, replacing the header row if there is one
+	Headers []string
+	// ColumnNames is the naming scheme for headerless input, see DefaultColumnNames
+	ColumnNames string
+
+	// SkipRows discards the first lines of the input, such as report titles
+	SkipRows int
+	// Comment skips lines starting with this prefix, e.g. "#" or "//"
+	Comment string
+	// HeaderRow is the 1-based record holding the header; records above it are dropped
+	HeaderRow int
+	// HeaderRows is the number of stacked header rows combined into keys such as "Q1.Revenue"
+	HeaderRows int
+	// SkipFooter drops the last records of the input, such as a "Total" row
+	SkipFooter int
+
+	// Layout is "table" (the default), "keyvalue" for two-column key,value files
+	// that become a single object, or "transpose" for files with one record per column.
+	// A keyvalue file's first row is a header like any other unless HasHeader is false.
+	Layout string
+
+	// Key is the column whose values index the keyed output format
+	Key string
+	// DuplicateKeys is the keyed format's policy for repeated keys: "error" (the
+	// default), "last" or "collect"
+	DuplicateKeys string
+	// DropKey removes the key column from the indexed records
+	DropKey bool
+
+	// GroupBy lists the columns whose shared values merge rows into one document
+	GroupBy []string
+	// Nest collects columns of the grouped rows into child arrays, see ParseNestSpec
+	Nest []NestSpec
+	// GroupSorted streams groups, relying on the input being sorted by GroupBy
+	GroupSorted bool
+
+	// Reshape is "unpivot" to turn wide records into long ones, or "pivot" for the reverse
+	Reshape string
+	// IDColumns identify a record when reshaping; pivot defaults to every other column
+	IDColumns []string
+	// VarName and ValueName are the long-form columns holding the wide column's
+	// name and value (default "variable" and "value")
+	VarName   string
+	ValueName string
+	// PivotAggregate combines values that pivot into the same cell: count, sum,
+	// avg, min, max, first (the default) or last
+	PivotAggregate string
+
+	// Aggregate replaces the rows with a summary, see ParseAggregateSpec
+	Aggregate *AggregateSpec
+
+	// MultiTable splits the input into tables at blank rows (or TableMarker rows)
+	// and outputs an object mapping each table's name to its rows
+	MultiTable bool
+	// TableMarker is the prefix of rows that start a new table; text after it names the table
+	TableMarker string
+	// TableTitles takes each table's name from its first row
+	TableTitles bool
+
+	// DetectFormulas reports cells that spreadsheet software would run as formulas
+	DetectFormulas bool
+	// OnWarning receives non-fatal problems found in the input
+	OnWarning func(message string) `json:"-"`
+}
+
+// namedDelimiters maps delimiter names accepted by SetDelimiter to their characters
+var namedDelimiters = map[string]string{
+	"comma":     ",",
+	"semicolon": ";",
+	"tab":       "\t",
+	"\\t":       "\t",
+	"pipe":      "|",
+	"space":     " ",
+}
+
+// SetDelimiter configures the delimiter from a user-supplied string. It accepts a
+// single character, a name such as "tab" or "pipe", "whitespace" for runs of
+// blanks, a "regex:" prefixed pattern, or any multi-character separator.
+func (o *ConversionOptions) SetDelimiter(value string) error {
+	if named, ok := namedDelimiters[value]; ok {
+		value = named
+	}
+
+	o.Separator, o.SeparatorPattern, o.SplitWhitespace = "", "", false
+	switch {
+	case value == "":
+		return fmt.Errorf("delimiter cannot be empty")
+	case value == "whitespace":
+		o.SplitWhitespace = true
+	case strings.HasPrefix(value, "regex:"):
+		o.SeparatorPattern = strings.TrimPrefix(value, "regex:")
+	case len([]rune(value)) == 1:
+		o.Delimiter = []rune(value)[0]
+	default:
+		o.Separator = value
+	}
+	return nil
 }
 
 // DefaultOptions returns default conversion options
@@ -0,0 +1,155 @@
+package converter
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+)
+
+// maxDirectiveLength bounds how far ahead the reader looks for a "sep=" line
+const maxDirectiveLength = 64
+
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// readSepDirective checks for the "sep=;" first line that Excel writes to name
+// the delimiter. When present, the line is consumed and the delimiter returned.
+func readSepDirective(reader io.Reader) (io.Reader, string) {
+	buffered := bufio.NewReader(reader)
+
+	// Look further ahead only when the input could start with the directive, so
+	// a slow stream is not held back
+	head, _ := buffered.Peek(4)
+	if !strings.EqualFold(string(head), "sep=") && !bytes.HasPrefix(head, utf8BOM) {
+		return buffered, ""
+	}
+	head, _ = buffered.Peek(maxDirectiveLength)
+
+	end := bytes.IndexByte(head, '\n')
+	if end < 0 {
+		if len(head) == maxDirectiveLength {
+			return buffered, ""
+		}
+		end = len(head)
+	}
+	line := bytes.TrimPrefix(head[:end], utf8BOM)
+	line = bytes.TrimSuffix(line, []byte("\r"))
+	if len(line) <= 4 || !strings.EqualFold(string(line[:4]), "sep=") {
+		return buffered, ""
+	}
+
+	buffered.Discard(min(end+1, len(head)))
+	return buffered, string(line[4:])
+}
+
+// excelTextValue unwraps the ="00123" form Excel uses to force a cell to text,
+// undoubling any quotes inside it. It reports false for any other cell.
+func excelTextValue(cell string) (string, bool) {
+	if len(cell) < 3 || cell[0] != '=' || cell[1] != '"' || cell[len(cell)-1] != '"' {
+		return "", false
+	}
+	inner := cell[2 : len(cell)-1]
+	if strings.Count(inner, `"`) != 2*strings.Count(inner, `""`) {
+		return "", false
+	}
+	return strings.ReplaceAll(inner, `""`, `"`), true
+}
+
+// excelTextReader quotes the unquoted ="00123" cells of delimited text, so
+// that encoding/csv can read them with strict quote checking and pass
+// ="00123" on for excelTextValue. Every other byte is passed through.
+type excelTextReader struct {
+	reader  *bufio.Reader
+	delim   string
+	comment string
+	quoted  bool // inside a quoted field continued from a previous line
+	pending []byte
+}
+
+func newExcelTextReader(reader io.Reader, delim rune, comment string) *excelTextReader {
+	return &excelTextReader{reader: bufio.NewReader(reader), delim: string(delim), comment: comment}
+}
+
+func (e *excelTextReader) Read(p []byte) (int, error) {
+	for len(e.pending) == 0 {
+		line, err := e.reader.ReadString('\n')
+		if line == "" {
+			return 0, err
+		}
+		e.pending = []byte(e.rewriteLine(line))
+	}
+	n := copy(p, e.pending)
+	e.pending = e.pending[n:]
+	return n, nil
+}
+
+// rewriteLine quotes the ="..." cells of one physical line, tracking quoted
+// fields that continue onto the next line
+func (e *excelTextReader) rewriteLine(line string) string {
+	if !e.quoted && (!strings.Contains(line, `"`) || (e.comment != "" && strings.HasPrefix(line, e.comment))) {
+		return line
+	}
+
+	var out strings.Builder
+	fieldStart := !e.quoted
+	for i := 0; i < len(line); {
+		switch {
+		case e.quoted:
+			if line[i] == '"' {
+				if i+1 < len(line) && line[i+1] == '"' {
+					out.WriteString(`""`)
+					i += 2
+					continue
+				}
+				e.quoted = false
+			}
+			out.WriteByte(line[i])
+			i++
+		case fieldStart && strings.HasPrefix(line[i:], `="`):
+			fieldStart = false
+			if end := e.textCellEnd(line, i); end > 0 {
+				out.WriteByte('"')
+				out.WriteString(strings.ReplaceAll(line[i:end], `"`, `""`))
+				out.WriteByte('"')
+				i = end
+				continue
+			}
+			out.WriteByte(line[i])
+			i++
+		case fieldStart && line[i] == '"':
+			fieldStart = false
+			e.quoted = true
+			out.WriteByte(line[i])
+			i++
+		case strings.HasPrefix(line[i:], e.delim):
+			fieldStart = true
+			out.WriteString(e.delim)
+			i += len(e.delim)
+		default:
+			fieldStart = false
+			out.WriteByte(line[i])
+			i++
+		}
+	}
+	return out.String()
+}
+
+// textCellEnd returns the end of the ="..." cell starting at i, or -1 when
+// the cell is not exactly that form up to the next delimiter or line end
+func (e *excelTextReader) textCellEnd(line string, i int) int {
+	for j := i + 2; j < len(line); j++ {
+		if line[j] != '"' {
+			continue
+		}
+		if j+1 < len(line) && line[j+1] == '"' {
+			j++
+			continue
+		}
+		rest := line[j+1:]
+		if rest == "" || rest == "\n" || rest == "\r\n" || strings.HasPrefix(rest, e.delim) {
+			return j + 1
+		}
+		return -1
+	}
+	return -1
+}
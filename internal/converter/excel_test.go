@@ -0,0 +1,88 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSepDirective(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"Semicolon", "sep=;\r\nname;age\r\nAnn;30\r\n", `[{"age":30,"name":"Ann"}]`},
+		{"Tab after BOM", "\xef\xbb\xbfsep=\t\nname\tage\nAnn\t30\n", `[{"age":30,"name":"Ann"}]`},
+		{"No directive", "name,age\nAnn,30\n", `[{"age":30,"name":"Ann"}]`},
+		{"Directive only", "sep=;", `[]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := DefaultOptions()
+			options.PrettyPrint = false
+			result, err := ConvertCSVToJSON(strings.NewReader(tt.input), options)
+			if err != nil {
+				t.Fatalf("ConvertCSVToJSON failed: %v", err)
+			}
+			if string(result) != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestExcelTextCells(t *testing.T) {
+	csvData := "id,zip,note\n=\"00123\",\"=\"\"0150\"\"\",\"=\"\"say \"\"\"\"hi\"\"\"\"\"\"\"\n"
+	options := DefaultOptions()
+	options.PrettyPrint = false
+
+	result, err := ConvertCSVToJSON(strings.NewReader(csvData), options)
+	if err != nil {
+		t.Fatalf("ConvertCSVToJSON failed: %v", err)
+	}
+	expected := `[{"id":"00123","note":"say \"hi\"","zip":"0150"}]`
+	if string(result) != expected {
+		t.Errorf("expected %s, got %s", expected, result)
+	}
+
+	// A quoted field spanning lines keeps its text, and CRLF lines unwrap too
+	csvData = "id,note\r\n=\"007\",\"a\n,=\"\"b\"\"\"\r\n=\"008\",=\"x\""
+	result, err = ConvertCSVToJSON(strings.NewReader(csvData), options)
+	if err != nil {
+		t.Fatalf("ConvertCSVToJSON failed: %v", err)
+	}
+	expected = `[{"id":"007","note":"a\n,=\"b\""},{"id":"008","note":"x"}]`
+	if string(result) != expected {
+		t.Errorf("expected %s, got %s", expected, result)
+	}
+
+	for _, cell := range []string{"=1+2", `="a" & "b"`, "=\"", "plain"} {
+		if _, ok := excelTextValue(cell); ok {
+			t.Errorf("excelTextValue(%q) should not unwrap", cell)
+		}
+	}
+}
+
+func TestMalformedQuotesStillFail(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		setup func(*ConversionOptions)
+	}{
+		{"Unterminated quote", "id,note\n1,\"oops\n2,fine\n", func(o *ConversionOptions) {}},
+		{"Unterminated quote before a footer", "id,note\n1,\"oops\n2,fine\n3,fine\nTotal,3\n", func(o *ConversionOptions) { o.SkipFooter = 1 }},
+		{"Bare quote", "id,note\n1,x\"y\n", func(o *ConversionOptions) {}},
+		{"Text cell followed by junk", "id,zip\n1,=\"0150\"x\n", func(o *ConversionOptions) {}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := DefaultOptions()
+			tt.setup(&options)
+			if result, err := ConvertCSVToJSON(strings.NewReader(tt.input), options); err == nil {
+				t.Errorf("expected a quote error, got %s", result)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package converter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// FixedWidthColumn describes one column of a fixed-width file using 1-based, inclusive positions
+type FixedWidthColumn struct {
+	Name  string
+	Start int
+	End   int
+}
+
+// ParseFixedWidthSpec parses a column spec such as "name:1-20,age:21-23".
+// Entries are separated by commas or newlines and may be written as a range
+// ("1-20") or as a width ("20") that continues from the previous column.
+// Names are optional; blank lines and lines starting with '#' are ignored.
+func ParseFixedWidthSpec(spec string) ([]FixedWidthColumn, error) {
+	var columns []FixedWidthColumn
+	next := 1
+
+	for _, line := range strings.Split(spec, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		for _, entry := range strings.Split(line, ",") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+
+			column := FixedWidthColumn{}
+			positions := entry
+			if idx := strings.LastIndex(entry, ":"); idx >= 0 {
+				column.Name = strings.TrimSpace(entry[:idx])
+				positions = strings.TrimSpace(entry[idx+1:])
+			}
+
+			if from, to, ok := strings.Cut(positions, "-"); ok {
+				start, err1 := strconv.Atoi(strings.TrimSpace(from))
+				end, err2 := strconv.Atoi(strings.TrimSpace(to))
+				if err1 != nil || err2 != nil || start < 1 || end < start {
+					return nil, fmt.Errorf("invalid column range %q", entry)
+				}
+				column.Start, column.End = start, end
+			} else {
+				width, err := strconv.Atoi(positions)
+				if err != nil || width < 1 {
+					return nil, fmt.Errorf("invalid column width %q", entry)
+				}
+				column.Start, column.End = next, next+width-1
+			}
+
+			next = column.End + 1
+			columns = append(columns, column)
+		}
+	}
+
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("fixed-width spec has no columns")
+	}
+	return columns, nil
+}
+
+// fixedWidthHeaders returns the column names from the spec, or nil if any column is unnamed
+func fixedWidthHeaders(columns []FixedWidthColumn) []string {
+	headers := make([]string, len(columns))
+	for i, column := range columns {
+		if column.Name == "" {
+			return nil
+		}
+		headers[i] = column.Name
+	}
+	return headers
+}
+
+// fixedWidthReader slices each line of the input into fields at fixed rune positions
+type fixedWidthReader struct {
+	scanner *bufio.Scanner
+	columns []FixedWidthColumn
+	comment string // lines starting with this prefix are skipped
+}
+
+func newFixedWidthReader(reader io.Reader, columns []FixedWidthColumn) *fixedWidthReader {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	return &fixedWidthReader{scanner: scanner, columns: columns}
+}
+
+// Read returns the trimmed fields of the next non-blank line
+func (f *fixedWidthReader) Read() ([]string, error) {
+	for f.scanner.Scan() {
+		line := []rune(strings.TrimSuffix(f.scanner.Text(), "\r"))
+		if strings.TrimSpace(string(line)) == "" || (f.comment != "" && strings.HasPrefix(string(line), f.comment)) {
+			continue
+		}
+
+		fields := make([]string, len(f.columns))
+		for i, column := range f.columns {
+			start, end := column.Start-1, column.End
+			if start >= len(line) {
+				continue
+			}
+			if end > len(line) {
+				end = len(line)
+			}
+			fields[i] = strings.TrimSpace(string(line[start:end]))
+		}
+		return fields, nil
+	}
+
+	if err := f.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
@@ -0,0 +1,54 @@
+package converter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFixedWidthSpec(t *testing.T) {
+	columns, err := ParseFixedWidthSpec("name:1-20, age:21-23\n# trailing columns\ncity:10\n5")
+	if err != nil {
+		t.Fatalf("ParseFixedWidthSpec() error = %v", err)
+	}
+
+	expected := []FixedWidthColumn{
+		{Name: "name", Start: 1, End: 20},
+		{Name: "age", Start: 21, End: 23},
+		{Name: "city", Start: 24, End: 33},
+		{Start: 34, End: 38},
+	}
+	if !reflect.DeepEqual(columns, expected) {
+		t.Errorf("ParseFixedWidthSpec() = %+v, want %+v", columns, expected)
+	}
+
+	for _, spec := range []string{"", "name:5-2", "name:x", "0"} {
+		if _, err := ParseFixedWidthSpec(spec); err == nil {
+			t.Errorf("ParseFixedWidthSpec(%q) expected error", spec)
+		}
+	}
+}
+
+func TestConvertFixedWidth(t *testing.T) {
+	columns, err := ParseFixedWidthSpec("name:1-10,age:11-13,city:14-20")
+	if err != nil {
+		t.Fatalf("ParseFixedWidthSpec() error = %v", err)
+	}
+
+	options := DefaultOptions()
+	options.InputFormat = "fixed"
+	options.FixedWidths = columns
+	options.PrettyPrint = false
+
+	input := "Jöhn      030NYC    \r\n\nJane      025LA\n"
+	result, err := ConvertCSVToJSON(strings.NewReader(input), options)
+	if err != nil {
+		t.Fatalf("ConvertCSVToJSON() error = %v", err)
+	}
+
+	for _, want := range []string{`{"age":30,"city":"NYC","name":"Jöhn"}`, `{"age":25,"city":"LA","name":"Jane"}`} {
+		if !strings.Contains(string(result), want) {
+			t.Errorf("ConvertCSVToJSON() = %s, missing %s", result, want)
+		}
+	}
+}
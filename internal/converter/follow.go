@@ -0,0 +1,257 @@
+package converter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// FollowCSV converts the CSV file at path like `tail -f`: existing records are
+// written first, then the file is polled every interval and each newly
+// appended complete record is written immediately as one line of NDJSON. The
+// header read at start-up is kept for the whole session. A truncated file is
+// read again from the beginning, and a rotated file (a new file at path) is
+// reopened, skipping its header row if it repeats the original one.
+// FollowCSV returns when ctx is cancelled.
+func FollowCSV(ctx context.Context, path string, writer io.Writer, options ConversionOptions, interval time.Duration) error {
+	options.OutputFormat = "ndjson"
+	if options.SkipFooter > 0 {
+		return fmt.Errorf("skip footer cannot be used when following a file")
+	}
+	if options.MultiTable || (options.Layout != "" && options.Layout != "table") {
+		return fmt.Errorf("multi-table input and the %q layout cannot be followed", options.Layout)
+	}
+	if hasRecordStages(options) {
+		return fmt.Errorf("grouped output cannot be followed")
+	}
+	dialect, err := resolveDialect(options)
+	if err != nil {
+		return err
+	}
+
+	// Preamble lines are dropped from the start of each file before parsing,
+	// so the per-chunk readers must not skip any rows themselves
+	preamble := options.SkipRows + max(options.HeaderRow-1, 0)
+	options.SkipRows, options.HeaderRow = 0, 0
+
+	f := &follower{
+		path:     path,
+		options:  options,
+		dialect:  dialect,
+		out:      bufio.NewWriter(writer),
+		preamble: preamble,
+	}
+	f.ultraOptions = DefaultUltraOptimizedOptions()
+	f.ultraOptions.ConversionOptions = options
+	f.encoder = newRecordEncoder(f.out, options)
+
+	if err := f.open(); err != nil {
+		return err
+	}
+	defer func() { f.file.Close() }()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := f.poll(); err != nil {
+			return err
+		}
+		if err := f.out.Flush(); err != nil {
+			return err
+		}
+		// Push the records through a compressor too, so readers see each poll's output
+		if flusher, ok := writer.(interface{ Flush() error }); ok {
+			if err := flusher.Flush(); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// follower holds the state of a FollowCSV session
+type follower struct {
+	path         string
+	options      ConversionOptions
+	ultraOptions UltraOptimizedOptions
+	dialect      Dialect
+	out          *bufio.Writer
+	encoder      *recordEncoder
+
+	file          *os.File
+	info          os.FileInfo
+	offset        int64
+	pending       []byte // complete lines that do not yet form a complete record, plus any partial line
+	headers       []string
+	headerRecords [][]string // the raw header rows read at start-up
+	newFile       bool       // the next record is the first one of a reopened file
+	matched       int        // header rows repeated so far at the start of a reopened file
+
+	preamble int // lines to drop at the start of each file
+	skipLeft int // preamble lines of the current file not yet dropped
+}
+
+// open opens path and starts reading it from the beginning
+func (f *follower) open() error {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	if f.file != nil {
+		f.file.Close()
+	}
+	f.file, f.info, f.offset, f.pending = file, info, 0, nil
+	f.newFile, f.skipLeft = true, f.preamble
+	return nil
+}
+
+// poll reads everything appended since the last call, handling truncation and rotation
+func (f *follower) poll() error {
+	if err := f.readAppended(); err != nil {
+		return err
+	}
+
+	current, err := os.Stat(f.path)
+	if err != nil {
+		// The file may be briefly missing during rotation
+		return nil
+	}
+	switch {
+	case !os.SameFile(current, f.info):
+		if err := f.open(); err != nil {
+			return nil
+		}
+		return f.readAppended()
+	case current.Size() < f.offset:
+		if _, err := f.file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		f.offset, f.pending, f.newFile, f.skipLeft = 0, nil, true, f.preamble
+		return f.readAppended()
+	}
+	return nil
+}
+
+// readAppended reads to the current end of the file and emits complete records
+func (f *follower) readAppended() error {
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := f.file.Read(buf)
+		f.offset += int64(n)
+		f.pending = append(f.pending, buf[:n]...)
+		if err == io.EOF || n == 0 {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return f.emitComplete()
+}
+
+// emitComplete converts the complete records at the start of the pending buffer
+func (f *follower) emitComplete() error {
+	// Keep the delimiter named by a "sep=" line for all the chunks that follow it
+	if first := bytes.IndexByte(f.pending, '\n'); f.newFile && f.skipLeft == f.preamble && first >= 0 {
+		if _, sep := readSepDirective(bytes.NewReader(f.pending[:first+1])); sep != "" {
+			if err := f.options.SetDelimiter(sep); err != nil {
+				return err
+			}
+			f.pending = f.pending[first+1:]
+		}
+	}
+
+	for f.skipLeft > 0 {
+		end := bytes.IndexByte(f.pending, '\n')
+		if end < 0 {
+			return nil
+		}
+		f.pending = f.pending[end+1:]
+		f.skipLeft--
+	}
+
+	end := bytes.LastIndexByte(f.pending, '\n')
+	if end < 0 {
+		return nil
+	}
+	// Only cut at a newline that is outside a quoted field
+	for end >= 0 && !f.balancedQuotes(f.pending[:end+1]) {
+		end = bytes.LastIndexByte(f.pending[:end], '\n')
+	}
+	if end < 0 {
+		return nil
+	}
+
+	chunk := string(f.pending[:end+1])
+	f.pending = append([]byte(nil), f.pending[end+1:]...)
+
+	reader, err := newRecordReader(strings.NewReader(chunk), f.options)
+	if err != nil {
+		return err
+	}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read CSV: %w", err)
+		}
+
+		firstOfFile := f.newFile
+		f.newFile = false
+		if firstOfFile {
+			f.matched = 0
+		}
+		if f.headers == nil {
+			f.headerRecords = append(f.headerRecords, record)
+			if len(f.headerRecords) < headerRowCount(f.options) {
+				continue
+			}
+			headers, rows, err := splitHeader(f.headerRecords, f.options)
+			if err != nil {
+				return err
+			}
+			f.headers = headers
+			if len(rows) == 0 {
+				continue
+			}
+		} else if f.options.HasHeader && (firstOfFile || f.matched > 0) && f.matched < len(f.headerRecords) &&
+			reflect.DeepEqual(record, f.headerRecords[f.matched]) {
+			// A reopened file repeats the header rows
+			f.matched++
+			continue
+		}
+		f.matched = 0
+
+		if err := f.encoder.encode(processRowUltra(record, f.headers, f.ultraOptions)); err != nil {
+			return err
+		}
+	}
+}
+
+// balancedQuotes reports whether data ends outside a quoted field
+func (f *follower) balancedQuotes(data []byte) bool {
+	if f.dialect.Quote == 0 || f.dialect.Escape != 0 {
+		return true
+	}
+	return bytes.Count(data, []byte(string(f.dialect.Quote)))%2 == 0
+}
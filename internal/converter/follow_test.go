@@ -0,0 +1,99 @@
+package converter
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent use
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestFollowCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.csv")
+	if err := os.WriteFile(path, []byte("id,event\n1,login\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out := &syncBuffer{}
+	done := make(chan error, 1)
+	go func() {
+		done <- FollowCSV(ctx, path, out, DefaultOptions(), 5*time.Millisecond)
+	}()
+
+	waitFor := func(lines int) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for strings.Count(out.String(), "\n") < lines {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %d lines, got %q", lines, out.String())
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	appendTo := func(data string) {
+		t.Helper()
+		file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		file.WriteString(data)
+		file.Close()
+	}
+
+	waitFor(1)
+
+	// A partial line is held back until it is complete
+	appendTo("2,\"multi")
+	time.Sleep(30 * time.Millisecond)
+	appendTo("\nline\"\n")
+	waitFor(2)
+
+	// Rotation: a new file replaces the old one and repeats the header
+	os.Rename(path, path+".1")
+	if err := os.WriteFile(path, []byte("id,event\n3,logout\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(3)
+
+	// Truncation: the file is read again from the start
+	if err := os.WriteFile(path, []byte("4,reset\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(4)
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("FollowCSV() error = %v", err)
+	}
+
+	expected := `{"event":"login","id":1}
+{"event":"multi\nline","id":2}
+{"event":"logout","id":3}
+{"event":"reset","id":4}
+`
+	if out.String() != expected {
+		t.Errorf("FollowCSV() = %q, want %q", out.String(), expected)
+	}
+}
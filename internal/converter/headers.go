@@ -0,0 +1,56 @@
+package converter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultColumnNames is the template for generated column names
+const DefaultColumnNames = "column_{n}"
+
+// ParseHeaderList splits a list of column names separated by commas or
+// newlines, as given to --headers or read from a headers file
+func ParseHeaderList(list string) []string {
+	var headers []string
+	for _, line := range strings.Split(list, "\n") {
+		for _, name := range strings.Split(line, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				headers = append(headers, name)
+			}
+		}
+	}
+	return headers
+}
+
+// columnNames generates names for count columns from a naming scheme: a
+// template containing {n} (1-based) or {i} (0-based), or "letters" for
+// spreadsheet-style names A, B, ..., Z, AA, AB
+func columnNames(scheme string, count int) ([]string, error) {
+	if scheme == "" {
+		scheme = DefaultColumnNames
+	}
+	if scheme != "letters" && !strings.Contains(scheme, "{n}") && !strings.Contains(scheme, "{i}") {
+		return nil, fmt.Errorf("column name scheme %q must be \"letters\" or contain {n} or {i}", scheme)
+	}
+
+	names := make([]string, count)
+	for i := range names {
+		if scheme == "letters" {
+			names[i] = columnLetters(i)
+			continue
+		}
+		name := strings.ReplaceAll(scheme, "{n}", strconv.Itoa(i+1))
+		names[i] = strings.ReplaceAll(name, "{i}", strconv.Itoa(i))
+	}
+	return names, nil
+}
+
+// columnLetters returns the spreadsheet name of a 0-based column index
+func columnLetters(index int) string {
+	var name []byte
+	for index++; index > 0; index = (index - 1) / 26 {
+		name = append([]byte{byte('A' + (index-1)%26)}, name...)
+	}
+	return string(name)
+}
@@ -0,0 +1,91 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSuppliedHeaders(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		setup    func(*ConversionOptions)
+		expected string
+		err      string
+	}{
+		{
+			name:     "Headerless feed",
+			input:    "1,Ann,ann@example.com\n",
+			setup:    func(o *ConversionOptions) { o.HasHeader = false; o.Headers = []string{"id", "name", "email"} },
+			expected: `[{"email":"ann@example.com","id":1,"name":"Ann"}]`,
+		},
+		{
+			name:     "Replacing the header row",
+			input:    "ID,Full Name\n1,Ann\n",
+			setup:    func(o *ConversionOptions) { o.Headers = []string{"id", "name"} },
+			expected: `[{"id":1,"name":"Ann"}]`,
+		},
+		{
+			name:  "Count mismatch",
+			input: "1,Ann,ann@example.com\n",
+			setup: func(o *ConversionOptions) { o.HasHeader = false; o.Headers = []string{"id", "name"} },
+			err:   "2 headers were supplied but the data has 3 columns",
+		},
+		{
+			name:     "Zero-based template",
+			input:    "a,b\n",
+			setup:    func(o *ConversionOptions) { o.HasHeader = false; o.ColumnNames = "col_{i}" },
+			expected: `[{"col_0":"a","col_1":"b"}]`,
+		},
+		{
+			name:     "Spreadsheet letters",
+			input:    "a,b\n",
+			setup:    func(o *ConversionOptions) { o.HasHeader = false; o.ColumnNames = "letters" },
+			expected: `[{"A":"a","B":"b"}]`,
+		},
+		{
+			name:  "Template without placeholder",
+			input: "a,b\n",
+			setup: func(o *ConversionOptions) { o.HasHeader = false; o.ColumnNames = "col" },
+			err:   "must be \"letters\" or contain {n} or {i}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := DefaultOptions()
+			options.PrettyPrint = false
+			tt.setup(&options)
+
+			result, err := ConvertCSVToJSON(strings.NewReader(tt.input), options)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ConvertCSVToJSON failed: %v", err)
+			}
+			if string(result) != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestColumnLetters(t *testing.T) {
+	tests := map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"}
+	for index, expected := range tests {
+		if got := columnLetters(index); got != expected {
+			t.Errorf("columnLetters(%d) = %s, want %s", index, got, expected)
+		}
+	}
+}
+
+func TestParseHeaderList(t *testing.T) {
+	got := ParseHeaderList("id, name\nemail\n\n")
+	if strings.Join(got, "|") != "id|name|email" {
+		t.Errorf("unexpected headers: %q", got)
+	}
+}
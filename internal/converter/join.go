@@ -0,0 +1,258 @@
+package converter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// JoinInput is one side of JoinCSVToJSON
+type JoinInput struct {
+	Name   string
+	Reader io.Reader
+	// Size is the input's length in bytes, or 0 when unknown
+	Size int64
+	// Options, when set, reads this side with its own format, header and row
+	// options instead of JoinOptions.ConversionOptions
+	Options *ConversionOptions
+}
+
+// readOptions returns the options this side is read with
+func (j JoinInput) readOptions(options ConversionOptions) ConversionOptions {
+	if j.Options != nil {
+		return *j.Options
+	}
+	return options
+}
+
+// JoinOptions configures JoinCSVToJSON
+type JoinOptions struct {
+	ConversionOptions
+	// LeftKey and RightKey are the columns matched between the input and the joined file
+	LeftKey  string
+	RightKey string
+	// How is "inner" (the default) to drop input rows without a match, or
+	// "left" to keep them with null joined fields
+	How string
+	// Prefix renames joined fields whose names collide with the input's
+	// (default: the joined file's name and "_", e.g. "customers_name")
+	Prefix string
+}
+
+// ParseJoinOn parses the join columns: "customer_id" when both files name the
+// key alike, or "customer_id=id" naming the input's column and then the joined file's
+func ParseJoinOn(on string) (string, string, error) {
+	left, right, found := strings.Cut(on, "=")
+	left, right = strings.TrimSpace(left), strings.TrimSpace(right)
+	if !found {
+		right = left
+	}
+	if left == "" || right == "" {
+		return "", "", fmt.Errorf("invalid join columns %q, expected 'column' or 'input_column=joined_column'", on)
+	}
+	return left, right, nil
+}
+
+// JoinCSVToJSON converts input with the fields of each matching row of join
+// merged into its records. The join key column is not repeated, and joined
+// fields that collide with the input's are renamed with options.Prefix. The
+// smaller side (by Size, when both are known) is held in a hash index and the
+// other is streamed; output follows the streamed file's row order, with
+// unmatched rows of an indexed input written last by a left join.
+func JoinCSVToJSON(input, join JoinInput, writer io.Writer, options JoinOptions) error {
+	if options.OutputFormat == "object" {
+		return fmt.Errorf("join supports array, ndjson and keyed output, not %q", options.OutputFormat)
+	}
+	if options.MultiTable || (options.Layout != "" && options.Layout != "table") {
+		return fmt.Errorf("join supports single tables in the table layout only")
+	}
+	switch options.How {
+	case "", "inner", "left":
+	default:
+		return fmt.Errorf("unknown join %q: use inner or left", options.How)
+	}
+
+	left, err := newSQLSource(input.Name, "", input.Reader, input.readOptions(options.ConversionOptions))
+	if err != nil {
+		return err
+	}
+	right, err := newSQLSource(join.Name, "", join.Reader, join.readOptions(options.ConversionOptions))
+	if err != nil {
+		return err
+	}
+	leftKey, err := joinColumn(left, options.LeftKey)
+	if err != nil {
+		return err
+	}
+	rightKey, err := joinColumn(right, options.RightKey)
+	if err != nil {
+		return err
+	}
+
+	prefix := options.Prefix
+	if prefix == "" {
+		prefix = tableAlias(join.Name) + "_"
+	}
+	headers := append([]string{}, left.headers...)
+	taken := make(map[string]bool)
+	for _, header := range left.headers {
+		taken[header] = true
+	}
+	rightNames := make([]string, len(right.headers))
+	for i, header := range right.headers {
+		if i == rightKey {
+			continue
+		}
+		if taken[header] {
+			header = prefix + header
+		}
+		taken[header] = true
+		rightNames[i] = header
+		headers = append(headers, header)
+	}
+
+	out := bufio.NewWriter(writer)
+	pipeline, err := newRecordPipeline(out, headers, options.ConversionOptions)
+	if err != nil {
+		return err
+	}
+	write := func(leftRow, rightRow []interface{}) error {
+		record := make(map[string]interface{}, len(headers))
+		for i, header := range left.headers {
+			record[header] = leftRow[i]
+		}
+		for i, name := range rightNames {
+			if name == "" {
+				continue
+			}
+			record[name] = nil
+			if rightRow != nil {
+				record[name] = rightRow[i]
+			}
+		}
+		return pipeline.write(record)
+	}
+
+	keepUnmatched := options.How == "left"
+	if input.Size > 0 && join.Size > 0 && input.Size < join.Size {
+		err = joinIndexedInput(left, right, leftKey, rightKey, keepUnmatched, write)
+	} else {
+		err = joinIndexedFile(left, right, leftKey, rightKey, keepUnmatched, write)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := pipeline.close(); err != nil {
+		return err
+	}
+	return out.Flush()
+}
+
+// joinColumn finds a join key column; an empty input has none and no rows to join
+func joinColumn(source *sqlSource, name string) (int, error) {
+	if len(source.headers) == 0 {
+		return -1, nil
+	}
+	for i, header := range source.headers {
+		if header == name {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("%s: join column %q not found", source.path, name)
+}
+
+// indexRows reads every row of source into a hash index on the key column
+func indexRows(source *sqlSource, key int) (map[string][][]interface{}, [][]interface{}, error) {
+	index := make(map[string][][]interface{})
+	var rows [][]interface{}
+	for {
+		row, err := source.next()
+		if err == io.EOF {
+			return index, rows, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		rows = append(rows, row)
+		if value, ok := joinKey(row[key]); ok {
+			index[value] = append(index[value], row)
+		}
+	}
+}
+
+// joinIndexedFile indexes the joined file and streams the input
+func joinIndexedFile(left, right *sqlSource, leftKey, rightKey int, keepUnmatched bool, write func(leftRow, rightRow []interface{}) error) error {
+	index, _, err := indexRows(right, rightKey)
+	if err != nil {
+		return err
+	}
+
+	for {
+		row, err := left.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var matches [][]interface{}
+		if value, ok := joinKey(row[leftKey]); ok {
+			matches = index[value]
+		}
+		if len(matches) == 0 && keepUnmatched {
+			matches = [][]interface{}{nil}
+		}
+		for _, match := range matches {
+			if err := write(row, match); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// joinIndexedInput indexes the input and streams the larger joined file,
+// writing the input rows that found no match at the end
+func joinIndexedInput(left, right *sqlSource, leftKey, rightKey int, keepUnmatched bool, write func(leftRow, rightRow []interface{}) error) error {
+	index, rows, err := indexRows(left, leftKey)
+	if err != nil {
+		return err
+	}
+
+	matched := make(map[string]bool)
+	for {
+		row, err := right.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		value, ok := joinKey(row[rightKey])
+		if !ok {
+			continue
+		}
+		for _, match := range index[value] {
+			if err := write(match, row); err != nil {
+				return err
+			}
+		}
+		matched[value] = true
+	}
+
+	if !keepUnmatched {
+		return nil
+	}
+	for _, row := range rows {
+		if value, ok := joinKey(row[leftKey]); ok && matched[value] {
+			continue
+		}
+		if err := write(row, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package converter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const (
+	joinOrders    = "order_id,customer_id,name,total\n10,1,widget,99.5\n11,2,gadget,10\n12,9,gizmo,5\n13,1,thing,20\n"
+	joinCustomers = "customer_id,name,tier\n1,Acme,gold\n2,Globex,silver\n3,Initech,bronze\n"
+)
+
+func TestJoinCSVToJSON(t *testing.T) {
+	tests := []struct {
+		name      string
+		how       string
+		leftSize  int64
+		rightSize int64
+		expected  string
+	}{
+		{
+			name: "inner join indexing the joined file",
+			expected: `{"customer_id":1,"customers_name":"Acme","name":"widget","order_id":10,"tier":"gold","total":99.5}
+{"customer_id":2,"customers_name":"Globex","name":"gadget","order_id":11,"tier":"silver","total":10}
+{"customer_id":1,"customers_name":"Acme","name":"thing","order_id":13,"tier":"gold","total":20}
+`,
+		},
+		{
+			name: "left join keeps unmatched rows",
+			how:  "left",
+			expected: `{"customer_id":1,"customers_name":"Acme","name":"widget","order_id":10,"tier":"gold","total":99.5}
+{"customer_id":2,"customers_name":"Globex","name":"gadget","order_id":11,"tier":"silver","total":10}
+{"customer_id":9,"customers_name":null,"name":"gizmo","order_id":12,"tier":null,"total":5}
+{"customer_id":1,"customers_name":"Acme","name":"thing","order_id":13,"tier":"gold","total":20}
+`,
+		},
+		{
+			name:      "left join indexing the smaller input",
+			how:       "left",
+			leftSize:  10,
+			rightSize: 1000,
+			// Matches follow the streamed customers file; unmatched orders come last
+			expected: `{"customer_id":1,"customers_name":"Acme","name":"widget","order_id":10,"tier":"gold","total":99.5}
+{"customer_id":1,"customers_name":"Acme","name":"thing","order_id":13,"tier":"gold","total":20}
+{"customer_id":2,"customers_name":"Globex","name":"gadget","order_id":11,"tier":"silver","total":10}
+{"customer_id":9,"customers_name":null,"name":"gizmo","order_id":12,"tier":null,"total":5}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := JoinOptions{ConversionOptions: DefaultOptions(), LeftKey: "customer_id", RightKey: "customer_id", How: tt.how}
+			options.OutputFormat = "ndjson"
+
+			var out bytes.Buffer
+			err := JoinCSVToJSON(
+				JoinInput{Name: "orders.csv", Reader: strings.NewReader(joinOrders), Size: tt.leftSize},
+				JoinInput{Name: "data/customers.csv", Reader: strings.NewReader(joinCustomers), Size: tt.rightSize},
+				&out, options)
+			if err != nil {
+				t.Fatalf("JoinCSVToJSON() error = %v", err)
+			}
+			if out.String() != tt.expected {
+				t.Errorf("JoinCSVToJSON() = %s, want %s", out.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestJoinCSVToJSONKeyNames(t *testing.T) {
+	options := JoinOptions{ConversionOptions: DefaultOptions(), Prefix: "c_"}
+	options.PrettyPrint = false
+	var err error
+	if options.LeftKey, options.RightKey, err = ParseJoinOn("customer_id = id"); err != nil {
+		t.Fatalf("ParseJoinOn() error = %v", err)
+	}
+
+	var out bytes.Buffer
+	err = JoinCSVToJSON(
+		JoinInput{Name: "orders.csv", Reader: strings.NewReader("order_id,customer_id,name\n10,2,widget\n")},
+		JoinInput{Name: "customers.csv", Reader: strings.NewReader("id,name\n2,Globex\n")},
+		&out, options)
+	if err != nil {
+		t.Fatalf("JoinCSVToJSON() error = %v", err)
+	}
+	if expected := `[{"c_name":"Globex","customer_id":2,"name":"widget","order_id":10}]`; out.String() != expected {
+		t.Errorf("JoinCSVToJSON() = %s, want %s", out.String(), expected)
+	}
+}
+
+func TestJoinCSVToJSONErrors(t *testing.T) {
+	tests := []struct {
+		options JoinOptions
+		message string
+	}{
+		{JoinOptions{LeftKey: "missing", RightKey: "customer_id"}, `orders.csv: join column "missing" not found`},
+		{JoinOptions{LeftKey: "customer_id", RightKey: "customer_id", How: "outer"}, `unknown join "outer"`},
+	}
+	for _, tt := range tests {
+		tt.options.ConversionOptions = DefaultOptions()
+		err := JoinCSVToJSON(
+			JoinInput{Name: "orders.csv", Reader: strings.NewReader(joinOrders)},
+			JoinInput{Name: "customers.csv", Reader: strings.NewReader(joinCustomers)},
+			&bytes.Buffer{}, tt.options)
+		if err == nil || !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("JoinCSVToJSON() error = %v, want it to contain %q", err, tt.message)
+		}
+	}
+
+	if _, _, err := ParseJoinOn("customer_id="); err == nil {
+		t.Error("ParseJoinOn() accepted an empty column name")
+	}
+}
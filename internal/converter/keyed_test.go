@@ -0,0 +1,113 @@
+package converter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestKeyedFormat(t *testing.T) {
+	input := "id,name,team\n1002,Bob,red\n1001,Ann,blue\n1002,Bea,green\n"
+
+	tests := []struct {
+		name     string
+		setup    func(*ConversionOptions)
+		expected string
+		err      string
+	}{
+		{
+			name:  "Duplicate keys are an error by default",
+			setup: func(o *ConversionOptions) {},
+			err:   `record 3: duplicate key "1002"`,
+		},
+		{
+			name:     "Last wins, keeping first-seen order",
+			setup:    func(o *ConversionOptions) { o.DuplicateKeys = DuplicateKeysLast },
+			expected: `{"1002":{"id":1002,"name":"Bea","team":"green"},"1001":{"id":1001,"name":"Ann","team":"blue"}}`,
+		},
+		{
+			name:     "Collect into arrays without the key field",
+			setup:    func(o *ConversionOptions) { o.DuplicateKeys = DuplicateKeysCollect; o.DropKey = true },
+			expected: `{"1002":[{"name":"Bob","team":"red"},{"name":"Bea","team":"green"}],"1001":[{"name":"Ann","team":"blue"}]}`,
+		},
+		{
+			name:  "Unknown key column",
+			setup: func(o *ConversionOptions) { o.Key = "uuid" },
+			err:   `record 1 has no value for key column "uuid"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := DefaultOptions()
+			options.PrettyPrint = false
+			options.OutputFormat = "keyed"
+			options.Key = "id"
+			tt.setup(&options)
+
+			result, err := ConvertCSVToJSON(strings.NewReader(input), options)
+			var streamed bytes.Buffer
+			streamErr := ConvertCSVToJSONStream(strings.NewReader(input), &streamed, options)
+
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("expected error containing %q, got %v", tt.err, err)
+				}
+				if streamErr == nil || !strings.Contains(streamErr.Error(), tt.err) {
+					t.Errorf("stream: expected error containing %q, got %v", tt.err, streamErr)
+				}
+				return
+			}
+			if err != nil || streamErr != nil {
+				t.Fatalf("conversion failed: %v, %v", err, streamErr)
+			}
+			if string(result) != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, result)
+			}
+			if streamed.String() != tt.expected {
+				t.Errorf("stream: expected %s, got %s", tt.expected, streamed.String())
+			}
+		})
+	}
+}
+
+func TestKeyedFormatKeepsKeyText(t *testing.T) {
+	input := "zip,price,id\n02134,1.50,123456789012345678901234\n"
+
+	tests := []struct {
+		key      string
+		expected string
+	}{
+		{"zip", `{"02134":{"id":1.2345678901234569e+23,"price":1.5,"zip":2134}}`},
+		{"price", `{"1.50":{"id":1.2345678901234569e+23,"price":1.5,"zip":2134}}`},
+		{"id", `{"123456789012345678901234":{"id":1.2345678901234569e+23,"price":1.5,"zip":2134}}`},
+	}
+
+	for _, tt := range tests {
+		options := DefaultOptions()
+		options.PrettyPrint = false
+		options.OutputFormat = "keyed"
+		options.Key = tt.key
+
+		result, err := ConvertCSVToJSON(strings.NewReader(input), options)
+		var streamed bytes.Buffer
+		streamErr := ConvertCSVToJSONStream(strings.NewReader(input), &streamed, options)
+		if err != nil || streamErr != nil {
+			t.Fatalf("conversion failed: %v, %v", err, streamErr)
+		}
+		if string(result) != tt.expected {
+			t.Errorf("key %s: expected %s, got %s", tt.key, tt.expected, result)
+		}
+		if streamed.String() != tt.expected {
+			t.Errorf("key %s stream: expected %s, got %s", tt.key, tt.expected, streamed.String())
+		}
+	}
+}
+
+func TestKeyedFormatRequiresKey(t *testing.T) {
+	options := DefaultOptions()
+	options.OutputFormat = "index"
+	if _, err := ConvertCSVToJSON(strings.NewReader("id\n1\n"), options); err == nil {
+		t.Error("expected an error without a key column")
+	}
+}
@@ -0,0 +1,56 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// transposeRecords swaps rows and columns, so that a vertical file with one
+// record per column becomes one record per row. Short rows are padded with
+// empty fields.
+func transposeRecords(records [][]string) [][]string {
+	width := 0
+	for _, record := range records {
+		width = max(width, len(record))
+	}
+
+	transposed := make([][]string, width)
+	for col := range transposed {
+		transposed[col] = make([]string, len(records))
+		for row, record := range records {
+			if col < len(record) {
+				transposed[col][row] = record[col]
+			}
+		}
+	}
+	return transposed
+}
+
+// convertToKeyValueUltra turns a two-column key,value file into a single JSON
+// object. The values are parsed like any other column; a repeated key keeps
+// its last value, and a row with more columns is an error.
+func convertToKeyValueUltra(dataRows [][]string, options UltraOptimizedOptions) ([]byte, error) {
+	for i, row := range dataRows {
+		if len(row) > 2 {
+			return nil, fmt.Errorf("keyvalue row %d has %d columns; expected key,value", i+1, len(row))
+		}
+	}
+	values := parseColumnUltra(dataRows, 1, options)
+
+	jsonObj := make(map[string]interface{}, len(dataRows))
+	for i, row := range dataRows {
+		if len(row) == 0 || cellText(row[0]) == "" {
+			continue
+		}
+		jsonObj[row[0]] = values[i]
+	}
+
+	if options.ConversionOptions.PrettyPrint && options.ConversionOptions.OutputFormat != "ndjson" {
+		return json.MarshalIndent(jsonObj, "", "  ")
+	}
+	data, err := json.Marshal(jsonObj)
+	if err != nil || options.ConversionOptions.OutputFormat != "ndjson" {
+		return data, err
+	}
+	return append(data, '\n'), nil
+}
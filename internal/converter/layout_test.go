@@ -0,0 +1,74 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLayouts(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		setup    func(*ConversionOptions)
+		expected string
+	}{
+		{
+			name:     "Key/value config",
+			input:    "key,value\nhost,example.com\nport,8080\ndebug,false\nport,9090\n",
+			setup:    func(o *ConversionOptions) { o.Layout = "keyvalue" },
+			expected: `{"debug":false,"host":"example.com","port":9090}`,
+		},
+		{
+			name:     "Key/value without header",
+			input:    "timeout,30\nname,\n",
+			setup:    func(o *ConversionOptions) { o.Layout = "keyvalue"; o.HasHeader = false },
+			expected: `{"name":null,"timeout":30}`,
+		},
+		{
+			name:     "Transposed records",
+			input:    "name,Ann,Bob\nage,30,25\nactive,true\n",
+			setup:    func(o *ConversionOptions) { o.Layout = "transpose" },
+			expected: `[{"active":true,"age":30,"name":"Ann"},{"active":null,"age":25,"name":"Bob"}]`,
+		},
+		{
+			name:     "Transposed object format",
+			input:    "name,Ann,Bob\nage,30,25\n",
+			setup:    func(o *ConversionOptions) { o.Layout = "transpose"; o.OutputFormat = "object" },
+			expected: `{"age":[30,25],"name":["Ann","Bob"]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := DefaultOptions()
+			options.PrettyPrint = false
+			tt.setup(&options)
+
+			result, err := ConvertCSVToJSON(strings.NewReader(tt.input), options)
+			if err != nil {
+				t.Fatalf("ConvertCSVToJSON failed: %v", err)
+			}
+			if string(result) != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestUnknownLayout(t *testing.T) {
+	options := DefaultOptions()
+	options.Layout = "diagonal"
+	if _, err := ConvertCSVToJSON(strings.NewReader("a\n1\n"), options); err == nil {
+		t.Error("expected an error for an unknown layout")
+	}
+}
+
+func TestKeyValueRejectsWideRows(t *testing.T) {
+	options := DefaultOptions()
+	options.Layout = "keyvalue"
+	options.HasHeader = false
+	_, err := ConvertCSVToJSON(strings.NewReader("host,localhost,primary\nport,8080,\n"), options)
+	if err == nil || !strings.Contains(err.Error(), "keyvalue row 1 has 3 columns") {
+		t.Errorf("expected an error for a three-column row, got %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package converter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// MergeInput is one named CSV source for MergeCSVToJSON
+type MergeInput struct {
+	Name   string
+	Reader io.Reader
+}
+
+// MergeOptions configures MergeCSVToJSON
+type MergeOptions struct {
+	ConversionOptions
+	// Aliases maps header names in the inputs to the key used in the output
+	Aliases map[string]string
+	// SourceField, when set, tags every record with its input name under this key
+	SourceField string
+}
+
+// mergeSource is an input whose header has been read
+type mergeSource struct {
+	name    string
+	reader  recordReader
+	headers []string
+	pending [][]string
+}
+
+// MergeCSVToJSON concatenates several CSV inputs into one JSON array or NDJSON
+// stream. The output keys are the union of all (aliased) headers in first-seen
+// order, and fields missing from an input are written as null. Only the header
+// rows are read up front; data rows are streamed.
+func MergeCSVToJSON(inputs []MergeInput, writer io.Writer, options MergeOptions) error {
+	if options.OutputFormat == "object" {
+		return fmt.Errorf("merge supports array and ndjson output, not %q", options.OutputFormat)
+	}
+	if options.MultiTable || (options.Layout != "" && options.Layout != "table") {
+		return fmt.Errorf("merge supports single tables in the table layout only")
+	}
+
+	var sources []mergeSource
+	var union []string
+	seen := make(map[string]bool)
+
+	for _, input := range inputs {
+		reader, err := newRecordReader(input.Reader, options.ConversionOptions)
+		if err != nil {
+			return err
+		}
+
+		headers, pending, err := readHeader(reader, options.ConversionOptions)
+		if err == io.EOF {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", input.Name, err)
+		}
+
+		for i, header := range headers {
+			if alias, ok := options.Aliases[header]; ok {
+				headers[i] = alias
+			}
+			if !seen[headers[i]] {
+				seen[headers[i]] = true
+				union = append(union, headers[i])
+			}
+		}
+		sources = append(sources, mergeSource{name: input.Name, reader: reader, headers: headers, pending: pending})
+	}
+
+	ultraOptions := DefaultUltraOptimizedOptions()
+	ultraOptions.ConversionOptions = options.ConversionOptions
+	out := bufio.NewWriter(writer)
+	columns := union
+	if options.SourceField != "" {
+		columns = append(append([]string{}, union...), options.SourceField)
+	}
+	pipeline, err := newRecordPipeline(out, columns, options.ConversionOptions)
+	if err != nil {
+		return err
+	}
+
+	for _, source := range sources {
+		for {
+			var row []string
+			if len(source.pending) > 0 {
+				row, source.pending = source.pending[0], source.pending[1:]
+			} else {
+				var err error
+				row, err = source.reader.Read()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					return fmt.Errorf("%s: failed to read CSV: %w", source.name, err)
+				}
+			}
+
+			record := make(map[string]interface{}, len(union)+1)
+			for _, header := range union {
+				record[header] = nil
+			}
+			for k, v := range processRowUltra(row, source.headers, ultraOptions) {
+				record[k] = v
+			}
+			if options.SourceField != "" {
+				record[options.SourceField] = source.name
+			}
+
+			if err := pipeline.write(record); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := pipeline.close(); err != nil {
+		return err
+	}
+	return out.Flush()
+}
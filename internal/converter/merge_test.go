@@ -0,0 +1,54 @@
+package converter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMergeCSVToJSON(t *testing.T) {
+	inputs := []MergeInput{
+		{Name: "jan.csv", Reader: strings.NewReader("id,E-mail\n1,a@example.com\n")},
+		{Name: "empty.csv", Reader: strings.NewReader("")},
+		{Name: "feb.csv", Reader: strings.NewReader("id,email,phone\n2,b@example.com,555\n3,c@example.com,\n")},
+	}
+
+	options := MergeOptions{
+		ConversionOptions: DefaultOptions(),
+		Aliases:           map[string]string{"E-mail": "email"},
+		SourceField:       "_source",
+	}
+	options.OutputFormat = "ndjson"
+
+	var out bytes.Buffer
+	if err := MergeCSVToJSON(inputs, &out, options); err != nil {
+		t.Fatalf("MergeCSVToJSON() error = %v", err)
+	}
+
+	expected := `{"_source":"jan.csv","email":"a@example.com","id":1,"phone":null}
+{"_source":"feb.csv","email":"b@example.com","id":2,"phone":555}
+{"_source":"feb.csv","email":"c@example.com","id":3,"phone":null}
+`
+	if out.String() != expected {
+		t.Errorf("MergeCSVToJSON() = %s, want %s", out.String(), expected)
+	}
+}
+
+func TestMergeCSVToJSONArray(t *testing.T) {
+	inputs := []MergeInput{
+		{Name: "a.csv", Reader: strings.NewReader("x\n1\n")},
+		{Name: "b.csv", Reader: strings.NewReader("y\n2\n")},
+	}
+	options := MergeOptions{ConversionOptions: DefaultOptions()}
+	options.PrettyPrint = false
+
+	var out bytes.Buffer
+	if err := MergeCSVToJSON(inputs, &out, options); err != nil {
+		t.Fatalf("MergeCSVToJSON() error = %v", err)
+	}
+
+	expected := `[{"x":1,"y":null},{"x":null,"y":2}]`
+	if out.String() != expected {
+		t.Errorf("MergeCSVToJSON() = %s, want %s", out.String(), expected)
+	}
+}
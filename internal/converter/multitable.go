@@ -0,0 +1,220 @@
+package converter
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// csvSection is one table of a multi-table input
+type csvSection struct {
+	name string
+	text strings.Builder
+}
+
+// convertMultiTable converts input holding several tables, separated by blank
+// rows or by rows starting with options.TableMarker, into a JSON object that
+// maps each table's name to its converted rows. Tables are named by the text
+// after the marker, by their first row when options.TableTitles is set, or
+// "table_1", "table_2" and so on.
+func convertMultiTable(reader io.Reader, options UltraOptimizedOptions) ([]byte, error) {
+	if options.OutputFormat == "ndjson" {
+		return nil, fmt.Errorf("multi-table input needs array or object output, not ndjson")
+	}
+	// Tables are split on the raw text, which only holds for delimited input
+	if options.InputFormat != "" && options.InputFormat != "csv" {
+		return nil, fmt.Errorf("multi-table input must be csv, not %s", options.InputFormat)
+	}
+
+	// File-level options apply once: the preamble lines above the header row
+	// are dropped before the input is split into tables, and the footer lines
+	// are dropped from the end of the last tables
+	reader, sep := readSepDirective(reader)
+	if sep != "" {
+		if err := options.SetDelimiter(sep); err != nil {
+			return nil, err
+		}
+	}
+	reader = skipLines(reader, options.SkipRows+max(options.HeaderRow-1, 0))
+
+	dialect, err := resolveDialect(options.ConversionOptions)
+	if err != nil {
+		return nil, err
+	}
+	sections, err := splitSections(reader, options.ConversionOptions, dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	sectionOptions := options
+	sectionOptions.MultiTable = false
+	sectionOptions.SkipRows = 0
+	sectionOptions.HeaderRow = 0
+	sectionOptions.SkipFooter = 0
+	sectionOptions.PrettyPrint = false
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	seen := make(map[string]int)
+	for i, section := range sections {
+		data, err := ConvertCSVToJSONUltra(strings.NewReader(section.text.String()), sectionOptions)
+		if err != nil {
+			return nil, fmt.Errorf("table %q: %w", section.name, err)
+		}
+
+		seen[section.name]++
+		name := section.name
+		if seen[name] > 1 {
+			name = fmt.Sprintf("%s_%d", name, seen[name])
+		}
+
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(name)
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(data)
+	}
+	buf.WriteByte('}')
+
+	if !options.PrettyPrint {
+		return buf.Bytes(), nil
+	}
+	var pretty bytes.Buffer
+	if err := json.Indent(&pretty, buf.Bytes(), "", "  "); err != nil {
+		return nil, err
+	}
+	return pretty.Bytes(), nil
+}
+
+// splitSections cuts the input into tables at blank rows, or at marker rows
+// when options.TableMarker is set. Newlines inside quoted fields never split.
+func splitSections(reader io.Reader, options ConversionOptions, dialect Dialect) ([]*csvSection, error) {
+	edges, err := titleEdges(options, dialect)
+	if err != nil {
+		return nil, err
+	}
+	tableTitle := func(line string) string {
+		if edges != nil {
+			return edges.ReplaceAllString(line, "")
+		}
+		return strings.Trim(line, titleCutset(dialect))
+	}
+
+	var sections []*csvSection
+	current := &csvSection{}
+	flush := func() {
+		if strings.TrimSpace(current.text.String()) != "" {
+			sections = append(sections, current)
+		}
+	}
+
+	buffered := bufio.NewReader(reader)
+	quoted := false
+	for {
+		line, err := buffered.ReadString('\n')
+		if line == "" && err != nil {
+			if err != io.EOF {
+				return nil, err
+			}
+			break
+		}
+
+		if !quoted {
+			switch {
+			case options.TableMarker != "" && strings.HasPrefix(strings.TrimSpace(line), options.TableMarker):
+				flush()
+				title := strings.TrimPrefix(strings.TrimSpace(line), options.TableMarker)
+				current = &csvSection{name: tableTitle(title)}
+				continue
+			case options.TableMarker == "" && tableTitle(line) == "":
+				flush()
+				current = &csvSection{}
+				continue
+			}
+		}
+
+		current.text.WriteString(line)
+		if !strings.HasSuffix(line, "\n") {
+			current.text.WriteByte('\n')
+		}
+		if dialect.Quote != 0 && dialect.Escape == 0 && strings.Count(line, string(dialect.Quote))%2 == 1 {
+			quoted = !quoted
+		}
+	}
+	flush()
+	sections = dropFooter(sections, options.SkipFooter)
+
+	for i, section := range sections {
+		if section.name == "" && options.TableTitles {
+			text := section.text.String()
+			first, rest, _ := strings.Cut(text, "\n")
+			section.name = tableTitle(first)
+			section.text.Reset()
+			section.text.WriteString(rest)
+		}
+		if section.name == "" {
+			section.name = fmt.Sprintf("table_%d", i+1)
+		}
+	}
+	return sections, nil
+}
+
+// dropFooter removes the last n lines of the input from the end of the last
+// sections, dropping any section that is left empty
+func dropFooter(sections []*csvSection, n int) []*csvSection {
+	for n > 0 && len(sections) > 0 {
+		last := sections[len(sections)-1]
+		lines := strings.SplitAfter(last.text.String(), "\n")
+		lines = lines[:len(lines)-1] // the text ends with a newline
+		if n >= len(lines) {
+			n -= len(lines)
+			sections = sections[:len(sections)-1]
+			continue
+		}
+		last.text.Reset()
+		last.text.WriteString(strings.Join(lines[:len(lines)-n], ""))
+		n = 0
+	}
+	return sections
+}
+
+// titleCutset holds the characters trimmed from a row to find its title: a
+// row such as "Customers,,," is titled "Customers", and a blank row, including
+// one made only of delimiters, has an empty title
+func titleCutset(dialect Dialect) string {
+	cutset := " \t\r\n"
+	if dialect.Delimiter != 0 {
+		cutset += string(dialect.Delimiter)
+	}
+	if dialect.Quote != 0 {
+		cutset += string(dialect.Quote)
+	}
+	return cutset
+}
+
+// titleEdges matches the leading and trailing runs of the title cutset and of
+// a multi-character or pattern separator, so that "Customers||||" is titled
+// "Customers" and "||||" is blank. It is nil for single-character delimiters.
+func titleEdges(options ConversionOptions, dialect Dialect) (*regexp.Regexp, error) {
+	separator := ""
+	switch {
+	case options.SeparatorPattern != "":
+		if _, err := regexp.Compile(options.SeparatorPattern); err != nil {
+			return nil, fmt.Errorf("invalid delimiter pattern: %w", err)
+		}
+		separator = options.SeparatorPattern
+	case len([]rune(options.Separator)) > 1:
+		separator = regexp.QuoteMeta(options.Separator)
+	default:
+		return nil, nil
+	}
+
+	edge := "(?:[" + regexp.QuoteMeta(titleCutset(dialect)) + "]|" + separator + ")+"
+	return regexp.Compile("^" + edge + "|" + edge + "$")
+}
@@ -0,0 +1,109 @@
+package converter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMultiTable(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		setup    func(*ConversionOptions)
+		expected string
+	}{
+		{
+			name:     "Blank rows with title rows",
+			input:    "Customers,,\nid,name,city\n1,Ann,Oslo\n,,\nOrders,,\norder,customer\n10,1\n11,1\n",
+			setup:    func(o *ConversionOptions) { o.TableTitles = true },
+			expected: `{"Customers":[{"city":"Oslo","id":1,"name":"Ann"}],"Orders":[{"customer":1,"order":10},{"customer":1,"order":11}]}`,
+		},
+		{
+			name:     "Unnamed tables keep input order",
+			input:    "b\n2\n\n\na\n1\n",
+			expected: `{"table_1":[{"b":2}],"table_2":[{"a":1}]}`,
+		},
+		{
+			name:     "Marker rows with a quoted blank line",
+			input:    "## Notes\nid,text\n1,\"first\n\nsecond\"\n## Notes\nid\n2\n",
+			setup:    func(o *ConversionOptions) { o.TableMarker = "##" },
+			expected: `{"Notes":[{"id":1,"text":"first\n\nsecond"}],"Notes_2":[{"id":2}]}`,
+		},
+		{
+			name:     "Footer dropped once from the last table",
+			input:    "id,name\n1,a\n\nid,total\n9,3\n",
+			setup:    func(o *ConversionOptions) { o.SkipFooter = 1 },
+			expected: `{"table_1":[{"id":1,"name":"a"}],"table_2":[]}`,
+		},
+		{
+			name:     "Footer table dropped whole",
+			input:    "Report\n\nid,name\n1,a\n2,b\n\nTotal,2\n",
+			setup:    func(o *ConversionOptions) { o.HeaderRow = 3; o.SkipFooter = 1 },
+			expected: `{"table_1":[{"id":1,"name":"a"},{"id":2,"name":"b"}]}`,
+		},
+		{
+			name:     "Multi-character separator",
+			input:    "Customers||||\nid||name\n1||Ann\n||||\nOrders||\norder||customer\n10||1\n",
+			setup:    func(o *ConversionOptions) { o.TableTitles = true; o.SetDelimiter("||") },
+			expected: `{"Customers":[{"id":1,"name":"Ann"}],"Orders":[{"customer":1,"order":10}]}`,
+		},
+		{
+			name:     "Separator pattern",
+			input:    "id ~ name\n1 ~ Ann\n ~ \nid ~ total\n9 ~ 3\n",
+			setup:    func(o *ConversionOptions) { o.SetDelimiter(`regex:\s*~\s*`) },
+			expected: `{"table_1":[{"id":1,"name":"Ann"}],"table_2":[{"id":9,"total":3}]}`,
+		},
+		{
+			name:     "Whitespace runs",
+			input:    "id  name\n1   Ann\n   \nid total\n9  3\n",
+			setup:    func(o *ConversionOptions) { o.SetDelimiter("whitespace") },
+			expected: `{"table_1":[{"id":1,"name":"Ann"}],"table_2":[{"id":9,"total":3}]}`,
+		},
+		{
+			name:     "Object format per table",
+			input:    "x,y\n1,2\n3,4\n",
+			setup:    func(o *ConversionOptions) { o.OutputFormat = "object" },
+			expected: `{"table_1":{"x":[1,3],"y":[2,4]}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := DefaultOptions()
+			options.PrettyPrint = false
+			options.MultiTable = true
+			if tt.setup != nil {
+				tt.setup(&options)
+			}
+
+			var out bytes.Buffer
+			if err := ConvertCSVToJSONStream(strings.NewReader(tt.input), &out, options); err != nil {
+				t.Fatalf("ConvertCSVToJSONStream failed: %v", err)
+			}
+			if out.String() != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, out.String())
+			}
+		})
+	}
+}
+
+func TestMultiTableRejectsNDJSON(t *testing.T) {
+	options := DefaultOptions()
+	options.MultiTable = true
+	options.OutputFormat = "ndjson"
+	if _, err := ConvertCSVToJSON(strings.NewReader("a\n1\n"), options); err == nil {
+		t.Error("expected an error for ndjson output")
+	}
+}
+
+func TestMultiTableRejectsNonCSVInput(t *testing.T) {
+	for _, format := range []string{"xlsx", "fixed"} {
+		options := DefaultOptions()
+		options.MultiTable = true
+		options.InputFormat = format
+		if _, err := ConvertCSVToJSON(strings.NewReader("a\n1\n\nb\n2\n"), options); err == nil {
+			t.Errorf("expected an error for %s input", format)
+		}
+	}
+}
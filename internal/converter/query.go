@@ -0,0 +1,907 @@
+package converter
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// QueryOpener opens a file named in a query's FROM or JOIN clause, returning
+// its contents and the options used to read it
+type QueryOpener func(path string) (io.Reader, ConversionOptions, error)
+
+// QueryCSV runs a SQL SELECT over CSV files and writes the result rows to
+// writer in options.OutputFormat. The supported subset is
+//
+//	SELECT [DISTINCT] items FROM file [alias]
+//	  [[INNER | LEFT] JOIN file [alias] ON a.col = b.col]
+//	  [WHERE cond] [GROUP BY exprs] [HAVING cond]
+//	  [ORDER BY expr|alias|position [ASC|DESC], ...] [LIMIT n [OFFSET m]]
+//
+// where conditions combine =, <>, <, <=, >, >=, LIKE, IN, BETWEEN and IS NULL
+// with AND, OR and NOT, and the aggregates are COUNT, SUM, AVG, MIN, MAX,
+// FIRST and LAST. Cells are typed as in conversion. The FROM file is streamed
+// and the JOIN file is held in a hash index; queries without GROUP BY or
+// ORDER BY stream their results and stop reading at the LIMIT.
+func QueryCSV(query string, open QueryOpener, writer io.Writer, options ConversionOptions) error {
+	if options.MultiTable || (options.Layout != "" && options.Layout != "table") {
+		return fmt.Errorf("query supports single tables in the table layout only")
+	}
+
+	q, err := parseQuery(query)
+	if err != nil {
+		return err
+	}
+
+	from, err := openSQLSource(q.from, open)
+	if err != nil {
+		return err
+	}
+	scope := from.scope()
+
+	var join *sqlIndex
+	if q.join != nil {
+		if q.join.table.alias == q.from.alias {
+			return fmt.Errorf("both tables are named %q; give one an alias", q.from.alias)
+		}
+		right, err := openSQLSource(q.join.table, open)
+		if err != nil {
+			return err
+		}
+		scope = append(scope, right.scope()...)
+		if join, err = newSQLIndex(q.join, right, scope, len(from.headers)); err != nil {
+			return err
+		}
+	}
+
+	e, err := newSQLExecutor(q, scope)
+	if err != nil {
+		return err
+	}
+
+	out := bufio.NewWriter(writer)
+	if options.OutputFormat == "object" {
+		e.out = newColumnWriter(out, e.names, options.PrettyPrint)
+	} else if e.out, err = newRecordPipeline(out, e.names, options); err != nil {
+		return err
+	}
+
+read:
+	for {
+		row, err := from.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		rows := [][]interface{}{row}
+		if join != nil {
+			rows = join.combine(row, q.join.left)
+		}
+		for _, row := range rows {
+			done, err := e.process(row)
+			if err != nil {
+				return err
+			}
+			if done {
+				break read
+			}
+		}
+	}
+
+	if err := e.finish(); err != nil {
+		return err
+	}
+	return out.Flush()
+}
+
+// sqlSource is a table being read: its header and a reader of typed rows
+type sqlSource struct {
+	alias   string
+	path    string
+	headers []string
+	reader  recordReader
+	pending [][]string
+	options UltraOptimizedOptions
+}
+
+func openSQLSource(table sqlTable, open QueryOpener) (*sqlSource, error) {
+	input, options, err := open(table.path)
+	if err != nil {
+		return nil, err
+	}
+	return newSQLSource(table.path, table.alias, input, options)
+}
+
+// newSQLSource reads the header of a table; an empty input has no columns or rows
+func newSQLSource(path, alias string, input io.Reader, options ConversionOptions) (*sqlSource, error) {
+	reader, err := newRecordReader(input, options)
+	if err != nil {
+		return nil, err
+	}
+
+	source := &sqlSource{alias: alias, path: path, reader: reader, options: DefaultUltraOptimizedOptions()}
+	source.options.ConversionOptions = options
+	source.headers, source.pending, err = readHeader(reader, options)
+	if err == io.EOF {
+		source.reader = nil
+		return source, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return source, nil
+}
+
+// scope lists the columns of the source for name resolution
+func (s *sqlSource) scope() []sqlScopeColumn {
+	columns := make([]sqlScopeColumn, len(s.headers))
+	for i, header := range s.headers {
+		columns[i] = sqlScopeColumn{table: s.alias, name: header}
+	}
+	return columns
+}
+
+// next returns the typed cells of the next row, or io.EOF
+func (s *sqlSource) next() ([]interface{}, error) {
+	var row []string
+	switch {
+	case len(s.pending) > 0:
+		row, s.pending = s.pending[0], s.pending[1:]
+	case s.reader == nil:
+		return nil, io.EOF
+	default:
+		var err error
+		if row, err = s.reader.Read(); err == io.EOF {
+			return nil, err
+		} else if err != nil {
+			return nil, fmt.Errorf("%s: failed to read CSV: %w", s.path, err)
+		}
+	}
+
+	values := make([]interface{}, len(s.headers))
+	for i := range values {
+		if i < len(row) {
+			values[i] = parseValueUltra(row[i], s.options.ConversionOptions.InferTypes, s.options.SIMDEnabled)
+		}
+	}
+	return values, nil
+}
+
+// sqlIndex holds the rows of a joined table by the value of its join column
+type sqlIndex struct {
+	rows      map[string][][]interface{}
+	leftIndex int
+	width     int
+}
+
+// newSQLIndex reads the joined table into a hash index. The ON condition must
+// compare one column of each table for equality.
+func newSQLIndex(join *sqlJoin, right *sqlSource, scope []sqlScopeColumn, leftWidth int) (*sqlIndex, error) {
+	on, ok := join.on.(*sqlCompare)
+	var leftColumn, rightColumn *sqlColumn
+	if ok && on.op == "=" {
+		leftColumn, _ = on.left.(*sqlColumn)
+		rightColumn, _ = on.right.(*sqlColumn)
+	}
+	if leftColumn == nil || rightColumn == nil {
+		return nil, fmt.Errorf("JOIN ... ON must compare a column of each table with =")
+	}
+
+	b := &sqlBinder{scope: scope}
+	if err := b.bind(leftColumn, "ON"); err != nil {
+		return nil, err
+	}
+	if err := b.bind(rightColumn, "ON"); err != nil {
+		return nil, err
+	}
+	if leftColumn.index >= leftWidth {
+		leftColumn, rightColumn = rightColumn, leftColumn
+	}
+	if leftColumn.index >= leftWidth || rightColumn.index < leftWidth {
+		return nil, fmt.Errorf("JOIN ... ON must compare a column of each table with =")
+	}
+
+	index := &sqlIndex{rows: make(map[string][][]interface{}), leftIndex: leftColumn.index, width: len(scope)}
+	for {
+		row, err := right.next()
+		if err == io.EOF {
+			return index, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if key, ok := joinKey(row[rightColumn.index-leftWidth]); ok {
+			index.rows[key] = append(index.rows[key], row)
+		}
+	}
+}
+
+// combine joins a row of the streamed table with its matching rows. An
+// unmatched row is dropped, or kept with null joined columns for a LEFT JOIN.
+func (x *sqlIndex) combine(row []interface{}, left bool) [][]interface{} {
+	var matches [][]interface{}
+	if key, ok := joinKey(row[x.leftIndex]); ok {
+		matches = x.rows[key]
+	}
+	if len(matches) == 0 && left {
+		matches = [][]interface{}{nil}
+	}
+
+	combined := make([][]interface{}, len(matches))
+	for i, match := range matches {
+		combined[i] = make([]interface{}, x.width)
+		copy(combined[i], row)
+		copy(combined[i][len(row):], match)
+	}
+	return combined
+}
+
+// joinKey renders a join value so that 7 and "7" match; null never matches
+func joinKey(value interface{}) (string, bool) {
+	if value == nil {
+		return "", false
+	}
+	return fmt.Sprint(value), true
+}
+
+// sqlScopeColumn is a column that expressions can refer to
+type sqlScopeColumn struct {
+	table string
+	name  string
+}
+
+// sqlBinder resolves column names to row positions and numbers the aggregates
+type sqlBinder struct {
+	scope      []sqlScopeColumn
+	aggregates []*sqlAggregate
+}
+
+// bind resolves the columns of an expression. Aggregates are rejected when
+// clause names a clause that cannot hold them, such as WHERE.
+func (b *sqlBinder) bind(expr sqlExpr, clause string) error {
+	switch e := expr.(type) {
+	case *sqlColumn:
+		return b.resolve(e)
+	case *sqlAggregate:
+		if clause != "" {
+			return fmt.Errorf("aggregate function %s is not allowed in %s", strings.ToUpper(e.function), clause)
+		}
+		for _, bound := range b.aggregates {
+			if bound == e {
+				// A select item referenced again from ORDER BY
+				return nil
+			}
+		}
+		if e.arg != nil {
+			if err := b.bind(e.arg, "another aggregate"); err != nil {
+				return err
+			}
+		}
+		e.index = len(b.aggregates)
+		b.aggregates = append(b.aggregates, e)
+	case *sqlNot:
+		return b.bind(e.operand, clause)
+	case *sqlIsNull:
+		return b.bind(e.operand, clause)
+	case *sqlLike:
+		return b.bind(e.operand, clause)
+	case *sqlLogical:
+		if err := b.bind(e.left, clause); err != nil {
+			return err
+		}
+		return b.bind(e.right, clause)
+	case *sqlCompare:
+		if err := b.bind(e.left, clause); err != nil {
+			return err
+		}
+		return b.bind(e.right, clause)
+	case *sqlIn:
+		if err := b.bind(e.operand, clause); err != nil {
+			return err
+		}
+		for _, value := range e.list {
+			if err := b.bind(value, clause); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// resolve finds a column by name, or by "table.name" when no column has the
+// dotted name itself (stacked headers produce names such as "Q1.Revenue")
+func (b *sqlBinder) resolve(c *sqlColumn) error {
+	if c.bound {
+		return nil
+	}
+	matches := b.find("", c.name)
+	if len(matches) == 0 {
+		if table, name, ok := strings.Cut(c.name, "."); ok {
+			matches = b.find(table, name)
+			if len(matches) > 0 {
+				c.table, c.name = table, name
+			}
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return fmt.Errorf("unknown column %q", c.name)
+	case 1:
+		c.index, c.bound = matches[0], true
+		return nil
+	}
+	return fmt.Errorf("ambiguous column %q: qualify it with a table name", c.name)
+}
+
+func (b *sqlBinder) find(table, name string) []int {
+	var matches []int
+	for i, column := range b.scope {
+		if column.name == name && (table == "" || column.table == table) {
+			matches = append(matches, i)
+		}
+	}
+	return matches
+}
+
+// sqlExpr is a bound expression evaluated against a row. Aggregates read the
+// results accumulated for the row's group.
+type sqlExpr interface {
+	eval(row []interface{}, aggregates []*aggregator) interface{}
+}
+
+// sqlColumn reads one cell of the row
+type sqlColumn struct {
+	table string
+	name  string
+	index int
+	bound bool
+}
+
+func (c *sqlColumn) eval(row []interface{}, aggregates []*aggregator) interface{} {
+	return row[c.index]
+}
+
+// sqlLiteral is a constant; a nil value is NULL
+type sqlLiteral struct {
+	value interface{}
+}
+
+func (l *sqlLiteral) eval(row []interface{}, aggregates []*aggregator) interface{} {
+	return l.value
+}
+
+// sqlAggregate is a call to an aggregate function; a nil argument is COUNT(*)
+type sqlAggregate struct {
+	function string
+	arg      sqlExpr
+	index    int
+}
+
+func (a *sqlAggregate) eval(row []interface{}, aggregates []*aggregator) interface{} {
+	return aggregates[a.index].result()
+}
+
+// sqlNot negates a condition; NOT NULL is NULL
+type sqlNot struct {
+	operand sqlExpr
+}
+
+func (n *sqlNot) eval(row []interface{}, aggregates []*aggregator) interface{} {
+	truth, known := sqlTruth(n.operand.eval(row, aggregates))
+	if !known {
+		return nil
+	}
+	return !truth
+}
+
+// sqlIsNull tests for a missing value
+type sqlIsNull struct {
+	operand sqlExpr
+	not     bool
+}
+
+func (n *sqlIsNull) eval(row []interface{}, aggregates []*aggregator) interface{} {
+	return (n.operand.eval(row, aggregates) == nil) != n.not
+}
+
+// sqlLogical is AND or OR with SQL's three-valued logic
+type sqlLogical struct {
+	and         bool
+	left, right sqlExpr
+}
+
+func (l *sqlLogical) eval(row []interface{}, aggregates []*aggregator) interface{} {
+	left, leftKnown := sqlTruth(l.left.eval(row, aggregates))
+	if leftKnown && left != l.and {
+		return left
+	}
+	right, rightKnown := sqlTruth(l.right.eval(row, aggregates))
+	if rightKnown && right != l.and {
+		return right
+	}
+	if !leftKnown || !rightKnown {
+		return nil
+	}
+	return l.and
+}
+
+// sqlCompare compares two values, numerically when both are numbers; a
+// comparison with NULL is NULL
+type sqlCompare struct {
+	op          string
+	left, right sqlExpr
+}
+
+func (c *sqlCompare) eval(row []interface{}, aggregates []*aggregator) interface{} {
+	left, right := c.left.eval(row, aggregates), c.right.eval(row, aggregates)
+	if left == nil || right == nil {
+		return nil
+	}
+	order := compareValues(left, right)
+	switch c.op {
+	case "=":
+		return order == 0
+	case "<>":
+		return order != 0
+	case "<":
+		return order < 0
+	case "<=":
+		return order <= 0
+	case ">":
+		return order > 0
+	}
+	return order >= 0
+}
+
+// sqlLike matches text against a LIKE pattern
+type sqlLike struct {
+	operand sqlExpr
+	pattern *regexp.Regexp
+	not     bool
+}
+
+func (l *sqlLike) eval(row []interface{}, aggregates []*aggregator) interface{} {
+	value := l.operand.eval(row, aggregates)
+	if value == nil {
+		return nil
+	}
+	return l.pattern.MatchString(fmt.Sprint(value)) != l.not
+}
+
+// sqlIn tests membership in a list of values
+type sqlIn struct {
+	operand sqlExpr
+	list    []sqlExpr
+	not     bool
+}
+
+func (in *sqlIn) eval(row []interface{}, aggregates []*aggregator) interface{} {
+	value := in.operand.eval(row, aggregates)
+	if value == nil {
+		return nil
+	}
+	for _, item := range in.list {
+		if other := item.eval(row, aggregates); other != nil && compareValues(value, other) == 0 {
+			return !in.not
+		}
+	}
+	return in.not
+}
+
+// sqlTruth interprets a value as a condition, so a boolean column can be
+// tested on its own as in "WHERE active". It reports false for NULL.
+func sqlTruth(value interface{}) (truth bool, known bool) {
+	switch v := value.(type) {
+	case nil:
+		return false, false
+	case bool:
+		return v, true
+	case string:
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b, true
+		}
+		return v != "", true
+	}
+	n, ok := toNumber(value)
+	return !ok || n != 0, true
+}
+
+// sqlGroup accumulates the aggregates of the rows sharing GROUP BY values,
+// keeping the first row for the other columns
+type sqlGroup struct {
+	row         []interface{}
+	aggregators []*aggregator
+}
+
+// sqlResult is an output row with its ORDER BY keys
+type sqlResult struct {
+	values []interface{}
+	keys   []interface{}
+}
+
+// sqlExecutor filters, groups, sorts and writes the rows of a query
+type sqlExecutor struct {
+	q          *sqlQuery
+	names      []string
+	exprs      []sqlExpr
+	orderBy    []sqlExpr
+	aggregates []*sqlAggregate
+	grouped    bool
+	width      int
+	// positions maps each select item to its output column, or -1 for a star
+	positions []int
+
+	groups  map[string]*sqlGroup
+	order   []string
+	results []sqlResult
+	seen    map[string]bool
+	skipped int
+	written int
+	out     recordWriter
+}
+
+// newSQLExecutor binds the query to the columns in scope, expands stars and
+// names the output columns
+func newSQLExecutor(q *sqlQuery, scope []sqlScopeColumn) (*sqlExecutor, error) {
+	e := &sqlExecutor{q: q, width: len(scope), groups: make(map[string]*sqlGroup), seen: make(map[string]bool)}
+	b := &sqlBinder{scope: scope}
+
+	if q.where != nil {
+		if err := b.bind(q.where, "WHERE"); err != nil {
+			return nil, err
+		}
+	}
+
+	used := make(map[string]bool)
+	addOutput := func(name string, expr sqlExpr) {
+		for n := 2; used[name]; n++ {
+			name = fmt.Sprintf("%s_%d", strings.TrimSuffix(name, fmt.Sprintf("_%d", n-1)), n)
+		}
+		used[name] = true
+		e.names = append(e.names, name)
+		e.exprs = append(e.exprs, expr)
+	}
+
+	for _, item := range q.items {
+		if item.star {
+			e.positions = append(e.positions, -1)
+			found := false
+			for i, column := range scope {
+				if item.table != "" && column.table != item.table {
+					continue
+				}
+				found = true
+				name := column.name
+				if used[name] {
+					// A joined column that collides with an earlier one keeps its table name
+					name = column.table + "." + column.name
+				}
+				addOutput(name, &sqlColumn{table: column.table, name: column.name, index: i, bound: true})
+			}
+			if item.table != "" && !found {
+				return nil, fmt.Errorf("unknown table %q in %s.*", item.table, item.table)
+			}
+			continue
+		}
+
+		if err := b.bind(item.expr, ""); err != nil {
+			return nil, err
+		}
+		e.positions = append(e.positions, len(e.exprs))
+		addOutput(outputName(item), item.expr)
+	}
+
+	for i, expr := range q.groupBy {
+		expr, err := e.selectReference(expr, "GROUP BY")
+		if err != nil {
+			return nil, err
+		}
+		if err := b.bind(expr, "GROUP BY"); err != nil {
+			return nil, err
+		}
+		q.groupBy[i] = expr
+	}
+
+	if q.having != nil {
+		q.having = e.aliasReferences(q.having)
+		if err := b.bind(q.having, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, order := range q.orderBy {
+		expr, err := e.selectReference(order.expr, "ORDER BY")
+		if err != nil {
+			return nil, err
+		}
+		if err := b.bind(expr, ""); err != nil {
+			return nil, err
+		}
+		e.orderBy = append(e.orderBy, expr)
+	}
+
+	e.aggregates = b.aggregates
+	e.grouped = len(q.groupBy) > 0 || len(e.aggregates) > 0
+	if q.having != nil && !e.grouped {
+		return nil, fmt.Errorf("HAVING requires GROUP BY or an aggregate function")
+	}
+	return e, nil
+}
+
+// selectReference resolves a 1-based position or an output alias used in
+// GROUP BY or ORDER BY to the select item's expression
+func (e *sqlExecutor) selectReference(expr sqlExpr, clause string) (sqlExpr, error) {
+	switch ref := expr.(type) {
+	case *sqlLiteral:
+		position, ok := ref.value.(int64)
+		if !ok {
+			break
+		}
+		if position < 1 || int(position) > len(e.exprs) {
+			return nil, fmt.Errorf("%s position %d is not in the select list", clause, position)
+		}
+		return e.exprs[position-1], nil
+	case *sqlColumn:
+		if ref.bound {
+			break
+		}
+		for i, item := range e.q.items {
+			if item.alias != "" && item.alias == ref.name {
+				return e.exprs[e.positions[i]], nil
+			}
+		}
+	}
+	return expr, nil
+}
+
+// aliasReferences replaces the output aliases within a HAVING condition with
+// the select items they name
+func (e *sqlExecutor) aliasReferences(expr sqlExpr) sqlExpr {
+	switch x := expr.(type) {
+	case *sqlColumn:
+		resolved, _ := e.selectReference(x, "HAVING")
+		return resolved
+	case *sqlNot:
+		x.operand = e.aliasReferences(x.operand)
+	case *sqlIsNull:
+		x.operand = e.aliasReferences(x.operand)
+	case *sqlLike:
+		x.operand = e.aliasReferences(x.operand)
+	case *sqlLogical:
+		x.left, x.right = e.aliasReferences(x.left), e.aliasReferences(x.right)
+	case *sqlCompare:
+		x.left, x.right = e.aliasReferences(x.left), e.aliasReferences(x.right)
+	case *sqlIn:
+		x.operand = e.aliasReferences(x.operand)
+		for i, item := range x.list {
+			x.list[i] = e.aliasReferences(item)
+		}
+	}
+	return expr
+}
+
+// outputName names the output column of a select item: its alias, the column
+// name, "avg_salary" for AVG(salary) as in ParseAggregateSpec, or the
+// expression as written
+func outputName(item sqlSelectItem) string {
+	if item.alias != "" {
+		return item.alias
+	}
+	switch e := item.expr.(type) {
+	case *sqlColumn:
+		return e.name
+	case *sqlAggregate:
+		if e.arg == nil {
+			return e.function
+		}
+		if column, ok := e.arg.(*sqlColumn); ok {
+			return e.function + "_" + column.name
+		}
+	}
+	return item.text
+}
+
+// process handles one joined row, reporting done once the LIMIT is reached
+func (e *sqlExecutor) process(row []interface{}) (bool, error) {
+	if e.q.where != nil {
+		if truth, _ := sqlTruth(e.q.where.eval(row, nil)); !truth {
+			return false, nil
+		}
+	}
+
+	if e.grouped {
+		return false, e.accumulate(row)
+	}
+	if len(e.orderBy) > 0 {
+		e.results = append(e.results, e.result(row, nil))
+		return false, nil
+	}
+	return e.emit(e.result(row, nil).values)
+}
+
+// accumulate adds a row to its group's aggregates
+func (e *sqlExecutor) accumulate(row []interface{}) error {
+	keys := make([]interface{}, len(e.q.groupBy))
+	for i, expr := range e.q.groupBy {
+		keys[i] = expr.eval(row, nil)
+	}
+	keyJSON, err := json.Marshal(keys)
+	if err != nil {
+		return err
+	}
+
+	group, ok := e.groups[string(keyJSON)]
+	if !ok {
+		group = e.newGroup(row)
+		e.groups[string(keyJSON)] = group
+		e.order = append(e.order, string(keyJSON))
+	}
+	for i, call := range e.aggregates {
+		if call.arg == nil {
+			group.aggregators[i].add(true)
+			continue
+		}
+		group.aggregators[i].add(call.arg.eval(row, nil))
+	}
+	return nil
+}
+
+func (e *sqlExecutor) newGroup(row []interface{}) *sqlGroup {
+	group := &sqlGroup{row: row}
+	for _, call := range e.aggregates {
+		a, _ := newAggregator(call.function)
+		group.aggregators = append(group.aggregators, a)
+	}
+	return group
+}
+
+// result evaluates the select list and ORDER BY keys for a row or group
+func (e *sqlExecutor) result(row []interface{}, aggregates []*aggregator) sqlResult {
+	result := sqlResult{values: make([]interface{}, len(e.exprs)), keys: make([]interface{}, len(e.orderBy))}
+	for i, expr := range e.exprs {
+		result.values[i] = expr.eval(row, aggregates)
+	}
+	for i, expr := range e.orderBy {
+		result.keys[i] = expr.eval(row, aggregates)
+	}
+	return result
+}
+
+// emit applies DISTINCT, OFFSET and LIMIT and writes an output row
+func (e *sqlExecutor) emit(values []interface{}) (bool, error) {
+	if e.q.limit >= 0 && e.written >= e.q.limit {
+		return true, nil
+	}
+	if e.q.distinct {
+		key, err := json.Marshal(values)
+		if err != nil {
+			return false, err
+		}
+		if e.seen[string(key)] {
+			return false, nil
+		}
+		e.seen[string(key)] = true
+	}
+	if e.skipped < e.q.offset {
+		e.skipped++
+		return false, nil
+	}
+
+	record := make(map[string]interface{}, len(e.names))
+	for i, name := range e.names {
+		record[name] = values[i]
+	}
+	if err := e.out.write(record); err != nil {
+		return false, err
+	}
+	e.written++
+	return e.q.limit >= 0 && e.written >= e.q.limit, nil
+}
+
+// finish summarizes the groups, sorts the held results and closes the output
+func (e *sqlExecutor) finish() error {
+	if e.grouped {
+		if len(e.q.groupBy) == 0 && len(e.order) == 0 {
+			// Aggregates over no rows still produce one row, with COUNT 0
+			e.groups[""] = e.newGroup(make([]interface{}, e.width))
+			e.order = append(e.order, "")
+		}
+		for _, key := range e.order {
+			group := e.groups[key]
+			if e.q.having != nil {
+				if truth, _ := sqlTruth(e.q.having.eval(group.row, group.aggregators)); !truth {
+					continue
+				}
+			}
+			result := e.result(group.row, group.aggregators)
+			if len(e.orderBy) == 0 {
+				if done, err := e.emit(result.values); done || err != nil {
+					if err != nil {
+						return err
+					}
+					break
+				}
+				continue
+			}
+			e.results = append(e.results, result)
+		}
+	}
+
+	sort.SliceStable(e.results, func(i, j int) bool {
+		for k, order := range e.q.orderBy {
+			c := compareNullable(e.results[i].keys[k], e.results[j].keys[k])
+			if c != 0 {
+				return (c < 0) != order.desc
+			}
+		}
+		return false
+	})
+	for _, result := range e.results {
+		if done, err := e.emit(result.values); done || err != nil {
+			if err != nil {
+				return err
+			}
+			break
+		}
+	}
+	return e.out.close()
+}
+
+// compareNullable orders values with NULL first
+func compareNullable(a, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	return compareValues(a, b)
+}
+
+// columnWriter collects records into the column-oriented object format
+type columnWriter struct {
+	out     *bufio.Writer
+	pretty  bool
+	columns map[string][]interface{}
+}
+
+func newColumnWriter(out *bufio.Writer, names []string, pretty bool) *columnWriter {
+	columns := make(map[string][]interface{}, len(names))
+	for _, name := range names {
+		columns[name] = []interface{}{}
+	}
+	return &columnWriter{out: out, pretty: pretty, columns: columns}
+}
+
+func (c *columnWriter) write(record map[string]interface{}) error {
+	for name, value := range record {
+		c.columns[name] = append(c.columns[name], value)
+	}
+	return nil
+}
+
+func (c *columnWriter) close() error {
+	var data []byte
+	var err error
+	if c.pretty {
+		data, err = json.MarshalIndent(c.columns, "", "  ")
+	} else {
+		data, err = json.Marshal(c.columns)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = c.out.Write(data)
+	return err
+}
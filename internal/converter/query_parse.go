@@ -0,0 +1,665 @@
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// sqlTokenKind classifies the tokens of a query
+type sqlTokenKind int
+
+const (
+	sqlEOF    sqlTokenKind = iota
+	sqlWord                // keyword, column, table alias or file name
+	sqlNumber              // integer or decimal literal
+	sqlString              // 'single quoted' text
+	sqlIdent               // "double quoted" or `back quoted` name
+	sqlSymbol              // operator or punctuation
+)
+
+// sqlToken is one lexical element of a query
+type sqlToken struct {
+	kind sqlTokenKind
+	text string
+	pos  int
+}
+
+// sqlSymbols are the operators and punctuation of the query language, longest first
+var sqlSymbols = []string{"<=", ">=", "<>", "!=", "=", "<", ">", ",", "(", ")", "*", ";"}
+
+// sqlKeywords cannot be used as unquoted aliases
+var sqlKeywords = map[string]bool{
+	"SELECT": true, "DISTINCT": true, "FROM": true, "WHERE": true, "GROUP": true, "BY": true,
+	"HAVING": true, "ORDER": true, "LIMIT": true, "OFFSET": true, "JOIN": true, "INNER": true,
+	"LEFT": true, "OUTER": true, "ON": true, "AS": true, "AND": true, "OR": true, "NOT": true,
+	"IS": true, "NULL": true, "LIKE": true, "IN": true, "BETWEEN": true, "ASC": true, "DESC": true,
+	"TRUE": true, "FALSE": true,
+}
+
+// lexQuery splits a query into tokens. Words run until whitespace, a quote or
+// a symbol, so file names such as data/2024-01.csv and dotted column names
+// such as o.customer_id need no quoting.
+func lexQuery(query string) ([]sqlToken, error) {
+	var tokens []sqlToken
+	i := 0
+	for i < len(query) {
+		c := query[i]
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' {
+			i++
+			continue
+		}
+
+		if c == '\'' || c == '"' || c == '`' {
+			text, end, err := lexQuoted(query, i)
+			if err != nil {
+				return nil, err
+			}
+			kind := sqlIdent
+			if c == '\'' {
+				kind = sqlString
+			}
+			tokens = append(tokens, sqlToken{kind: kind, text: text, pos: i})
+			i = end
+			continue
+		}
+
+		if symbol := sqlSymbolAt(query, i); symbol != "" {
+			tokens = append(tokens, sqlToken{kind: sqlSymbol, text: symbol, pos: i})
+			i += len(symbol)
+			continue
+		}
+		if c == '!' {
+			return nil, fmt.Errorf("syntax error at position %d: unexpected %q", i+1, c)
+		}
+
+		start := i
+		for i < len(query) && !strings.ContainsRune(" \t\n\r'\"`!", rune(query[i])) && sqlSymbolAt(query, i) == "" {
+			i++
+		}
+		word := query[start:i]
+		kind := sqlWord
+		if isSQLNumber(word) {
+			kind = sqlNumber
+		}
+		tokens = append(tokens, sqlToken{kind: kind, text: word, pos: start})
+	}
+	return append(tokens, sqlToken{kind: sqlEOF, pos: len(query)}), nil
+}
+
+// sqlSymbolAt returns the symbol starting at query[i], if any
+func sqlSymbolAt(query string, i int) string {
+	for _, symbol := range sqlSymbols {
+		if strings.HasPrefix(query[i:], symbol) {
+			return symbol
+		}
+	}
+	return ""
+}
+
+// lexQuoted reads the quoted text starting at query[start]; a doubled quote
+// stands for itself
+func lexQuoted(query string, start int) (string, int, error) {
+	quote := query[start]
+	var text strings.Builder
+	for i := start + 1; i < len(query); i++ {
+		if query[i] != quote {
+			text.WriteByte(query[i])
+			continue
+		}
+		if i+1 < len(query) && query[i+1] == quote {
+			text.WriteByte(quote)
+			i++
+			continue
+		}
+		return text.String(), i + 1, nil
+	}
+	return "", 0, fmt.Errorf("syntax error at position %d: unterminated %c", start+1, quote)
+}
+
+// isSQLNumber reports whether a word is a numeric literal rather than a name
+func isSQLNumber(word string) bool {
+	digits := strings.TrimLeft(word, "+-.")
+	if digits == "" || digits[0] < '0' || digits[0] > '9' {
+		return false
+	}
+	_, err := strconv.ParseFloat(word, 64)
+	return err == nil
+}
+
+// sqlQuery is a parsed SELECT statement
+type sqlQuery struct {
+	distinct bool
+	items    []sqlSelectItem
+	from     sqlTable
+	join     *sqlJoin
+	where    sqlExpr
+	groupBy  []sqlExpr
+	having   sqlExpr
+	orderBy  []sqlOrder
+	limit    int // -1 when there is no LIMIT
+	offset   int
+}
+
+// sqlTable is a CSV file named in FROM or JOIN
+type sqlTable struct {
+	path  string
+	alias string
+}
+
+// sqlJoin joins a second table on an equality between one column of each
+type sqlJoin struct {
+	table sqlTable
+	left  bool
+	on    sqlExpr
+}
+
+// sqlSelectItem is one entry of the select list: an expression or a star,
+// optionally limited to one table as in "c.*"
+type sqlSelectItem struct {
+	expr  sqlExpr
+	star  bool
+	table string
+	alias string
+	text  string // the expression as written, used to name computed columns
+}
+
+// sqlOrder is one ORDER BY key
+type sqlOrder struct {
+	expr sqlExpr
+	desc bool
+}
+
+// sqlParser is a recursive descent parser over the tokens of one query
+type sqlParser struct {
+	query  string
+	tokens []sqlToken
+	pos    int
+}
+
+// parseQuery parses a SELECT statement
+func parseQuery(query string) (*sqlQuery, error) {
+	tokens, err := lexQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &sqlParser{query: query, tokens: tokens}
+	return p.parseSelect()
+}
+
+func (p *sqlParser) peek() sqlToken {
+	return p.tokens[p.pos]
+}
+
+func (p *sqlParser) next() sqlToken {
+	token := p.tokens[p.pos]
+	if token.kind != sqlEOF {
+		p.pos++
+	}
+	return token
+}
+
+// isKeyword reports whether the next token is the given keyword
+func (p *sqlParser) isKeyword(keyword string) bool {
+	token := p.peek()
+	return token.kind == sqlWord && strings.EqualFold(token.text, keyword)
+}
+
+// keyword consumes the next token if it is the given keyword
+func (p *sqlParser) keyword(keyword string) bool {
+	if p.isKeyword(keyword) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// symbol consumes the next token if it is the given symbol
+func (p *sqlParser) symbol(symbol string) bool {
+	if token := p.peek(); token.kind == sqlSymbol && token.text == symbol {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *sqlParser) expectKeyword(keyword string) error {
+	if !p.keyword(keyword) {
+		return p.unexpected(keyword)
+	}
+	return nil
+}
+
+func (p *sqlParser) expectSymbol(symbol string) error {
+	if !p.symbol(symbol) {
+		return p.unexpected(fmt.Sprintf("%q", symbol))
+	}
+	return nil
+}
+
+// unexpected reports that the next token is not what the grammar expects
+func (p *sqlParser) unexpected(expected string) error {
+	token := p.peek()
+	if token.kind == sqlEOF {
+		return fmt.Errorf("syntax error: expected %s at end of query", expected)
+	}
+	return fmt.Errorf("syntax error at position %d: expected %s, found %q", token.pos+1, expected, token.text)
+}
+
+func (p *sqlParser) parseSelect() (*sqlQuery, error) {
+	q := &sqlQuery{limit: -1}
+	if err := p.expectKeyword("SELECT"); err != nil {
+		return nil, err
+	}
+	q.distinct = p.keyword("DISTINCT")
+
+	for {
+		item, err := p.parseSelectItem()
+		if err != nil {
+			return nil, err
+		}
+		q.items = append(q.items, item)
+		if !p.symbol(",") {
+			break
+		}
+	}
+
+	if err := p.expectKeyword("FROM"); err != nil {
+		return nil, err
+	}
+	table, err := p.parseTable()
+	if err != nil {
+		return nil, err
+	}
+	q.from = table
+
+	if q.join, err = p.parseJoin(); err != nil {
+		return nil, err
+	}
+
+	if p.keyword("WHERE") {
+		if q.where, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.keyword("GROUP") {
+		if err := p.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+		for {
+			expr, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			q.groupBy = append(q.groupBy, expr)
+			if !p.symbol(",") {
+				break
+			}
+		}
+	}
+
+	if p.keyword("HAVING") {
+		if q.having, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.keyword("ORDER") {
+		if err := p.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+		for {
+			expr, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			order := sqlOrder{expr: expr}
+			if p.keyword("DESC") {
+				order.desc = true
+			} else {
+				p.keyword("ASC")
+			}
+			q.orderBy = append(q.orderBy, order)
+			if !p.symbol(",") {
+				break
+			}
+		}
+	}
+
+	if p.keyword("LIMIT") {
+		if q.limit, err = p.parseCount("LIMIT"); err != nil {
+			return nil, err
+		}
+		if p.keyword("OFFSET") {
+			if q.offset, err = p.parseCount("OFFSET"); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	p.symbol(";")
+	if p.peek().kind != sqlEOF {
+		return nil, p.unexpected("end of query")
+	}
+	return q, nil
+}
+
+// parseCount parses the non-negative integer following LIMIT or OFFSET
+func (p *sqlParser) parseCount(clause string) (int, error) {
+	token := p.next()
+	n, err := strconv.Atoi(token.text)
+	if token.kind != sqlNumber || err != nil || n < 0 {
+		return 0, fmt.Errorf("syntax error at position %d: %s needs a non-negative integer, found %q", token.pos+1, clause, token.text)
+	}
+	return n, nil
+}
+
+func (p *sqlParser) parseSelectItem() (sqlSelectItem, error) {
+	if p.symbol("*") {
+		return sqlSelectItem{star: true}, nil
+	}
+	// "c.*" lexes as the word "c." followed by a star
+	if token := p.peek(); token.kind == sqlWord && strings.HasSuffix(token.text, ".") {
+		if next := p.tokens[p.pos+1]; next.kind == sqlSymbol && next.text == "*" {
+			p.pos += 2
+			return sqlSelectItem{star: true, table: strings.TrimSuffix(token.text, ".")}, nil
+		}
+	}
+
+	start := p.peek().pos
+	expr, err := p.parseExpr()
+	if err != nil {
+		return sqlSelectItem{}, err
+	}
+	item := sqlSelectItem{expr: expr, text: strings.TrimSpace(p.query[start:p.peek().pos])}
+	if item.alias, err = p.parseAlias(); err != nil {
+		return sqlSelectItem{}, err
+	}
+	return item, nil
+}
+
+// parseAlias parses an optional "AS name" or bare name after a select item or table
+func (p *sqlParser) parseAlias() (string, error) {
+	explicit := p.keyword("AS")
+	token := p.peek()
+	switch {
+	case token.kind == sqlIdent || (token.kind == sqlWord && !sqlKeywords[strings.ToUpper(token.text)]):
+		p.pos++
+		return token.text, nil
+	case explicit:
+		return "", p.unexpected("an alias")
+	}
+	return "", nil
+}
+
+func (p *sqlParser) parseTable() (sqlTable, error) {
+	token := p.next()
+	if token.kind != sqlWord && token.kind != sqlIdent && token.kind != sqlString {
+		p.pos--
+		return sqlTable{}, p.unexpected("a file name")
+	}
+	table := sqlTable{path: token.text}
+	alias, err := p.parseAlias()
+	if err != nil {
+		return table, err
+	}
+	table.alias = alias
+	if alias == "" {
+		table.alias = tableAlias(token.text)
+	}
+	return table, nil
+}
+
+// tableAlias names a table after its file, without directories or extensions
+func tableAlias(path string) string {
+	name := path[strings.LastIndexAny(path, `/\`)+1:]
+	if i := strings.Index(name, "."); i > 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+func (p *sqlParser) parseJoin() (*sqlJoin, error) {
+	join := &sqlJoin{}
+	explicit := true
+	switch {
+	case p.keyword("LEFT"):
+		join.left = true
+		p.keyword("OUTER")
+	case p.keyword("INNER"):
+	default:
+		explicit = false
+	}
+	if !p.keyword("JOIN") {
+		if explicit {
+			return nil, p.unexpected("JOIN")
+		}
+		return nil, nil
+	}
+
+	table, err := p.parseTable()
+	if err != nil {
+		return nil, err
+	}
+	join.table = table
+	if err := p.expectKeyword("ON"); err != nil {
+		return nil, err
+	}
+	if join.on, err = p.parseExpr(); err != nil {
+		return nil, err
+	}
+	if p.isKeyword("JOIN") || p.isKeyword("LEFT") || p.isKeyword("INNER") {
+		return nil, fmt.Errorf("syntax error at position %d: only one JOIN is supported", p.peek().pos+1)
+	}
+	return join, nil
+}
+
+// parseExpr parses an expression; precedence from loosest to tightest is
+// OR, AND, NOT, then comparisons
+func (p *sqlParser) parseExpr() (sqlExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &sqlLogical{and: false, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *sqlParser) parseAnd() (sqlExpr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("AND") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &sqlLogical{and: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *sqlParser) parseNot() (sqlExpr, error) {
+	if p.keyword("NOT") {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &sqlNot{operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *sqlParser) parseComparison() (sqlExpr, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	if token := p.peek(); token.kind == sqlSymbol {
+		switch token.text {
+		case "=", "<>", "!=", "<", "<=", ">", ">=":
+			p.pos++
+			right, err := p.parsePrimary()
+			if err != nil {
+				return nil, err
+			}
+			op := token.text
+			if op == "!=" {
+				op = "<>"
+			}
+			return &sqlCompare{op: op, left: left, right: right}, nil
+		}
+	}
+
+	if p.keyword("IS") {
+		not := p.keyword("NOT")
+		if err := p.expectKeyword("NULL"); err != nil {
+			return nil, err
+		}
+		return &sqlIsNull{operand: left, not: not}, nil
+	}
+
+	not := p.keyword("NOT")
+	switch {
+	case p.keyword("LIKE"):
+		token := p.next()
+		if token.kind != sqlString {
+			p.pos--
+			return nil, p.unexpected("a quoted LIKE pattern")
+		}
+		return &sqlLike{operand: left, pattern: likePattern(token.text), not: not}, nil
+	case p.keyword("IN"):
+		if err := p.expectSymbol("("); err != nil {
+			return nil, err
+		}
+		in := &sqlIn{operand: left, not: not}
+		for {
+			value, err := p.parsePrimary()
+			if err != nil {
+				return nil, err
+			}
+			in.list = append(in.list, value)
+			if !p.symbol(",") {
+				break
+			}
+		}
+		return in, p.expectSymbol(")")
+	case p.keyword("BETWEEN"):
+		low, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectKeyword("AND"); err != nil {
+			return nil, err
+		}
+		high, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		var between sqlExpr = &sqlLogical{
+			and:   true,
+			left:  &sqlCompare{op: ">=", left: left, right: low},
+			right: &sqlCompare{op: "<=", left: left, right: high},
+		}
+		if not {
+			between = &sqlNot{operand: between}
+		}
+		return between, nil
+	case not:
+		return nil, p.unexpected("LIKE, IN or BETWEEN after NOT")
+	}
+	return left, nil
+}
+
+func (p *sqlParser) parsePrimary() (sqlExpr, error) {
+	token := p.next()
+	switch token.kind {
+	case sqlNumber:
+		if n, err := strconv.ParseInt(token.text, 10, 64); err == nil {
+			return &sqlLiteral{value: n}, nil
+		}
+		f, _ := strconv.ParseFloat(token.text, 64)
+		return &sqlLiteral{value: f}, nil
+	case sqlString:
+		return &sqlLiteral{value: token.text}, nil
+	case sqlIdent:
+		return &sqlColumn{name: token.text}, nil
+	case sqlSymbol:
+		if token.text == "(" {
+			expr, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			return expr, p.expectSymbol(")")
+		}
+	case sqlWord:
+		switch strings.ToUpper(token.text) {
+		case "NULL":
+			return &sqlLiteral{}, nil
+		case "TRUE":
+			return &sqlLiteral{value: true}, nil
+		case "FALSE":
+			return &sqlLiteral{value: false}, nil
+		}
+		if p.symbol("(") {
+			return p.parseCall(token)
+		}
+		if sqlKeywords[strings.ToUpper(token.text)] {
+			break
+		}
+		return &sqlColumn{name: token.text}, nil
+	}
+	p.pos--
+	return nil, p.unexpected("a column or value")
+}
+
+// parseCall parses the arguments of an aggregate function call
+func (p *sqlParser) parseCall(name sqlToken) (sqlExpr, error) {
+	function := strings.ToLower(name.text)
+	if !aggregateFunctions[function] {
+		return nil, fmt.Errorf("syntax error at position %d: unknown function %s", name.pos+1, name.text)
+	}
+
+	call := &sqlAggregate{function: function}
+	if p.symbol("*") {
+		if function != "count" {
+			return nil, fmt.Errorf("syntax error at position %d: only COUNT accepts *", name.pos+1)
+		}
+	} else {
+		arg, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		call.arg = arg
+	}
+	return call, p.expectSymbol(")")
+}
+
+// likePattern compiles a LIKE pattern, where % matches any run of characters
+// and _ matches one, into a case-insensitive regular expression
+func likePattern(pattern string) *regexp.Regexp {
+	var expr strings.Builder
+	expr.WriteString("(?is)^")
+	for _, c := range pattern {
+		switch c {
+		case '%':
+			expr.WriteString(".*")
+		case '_':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	expr.WriteString("$")
+	return regexp.MustCompile(expr.String())
+}
@@ -0,0 +1,98 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLexQuery(t *testing.T) {
+	tokens, err := lexQuery(`SELECT o.id, "First Name" FROM data/2024-01.csv o WHERE note <> 'it''s' AND n >= -1.5`)
+	if err != nil {
+		t.Fatalf("lexQuery() error = %v", err)
+	}
+
+	var got []string
+	for _, token := range tokens {
+		got = append(got, token.text)
+	}
+	expected := "SELECT|o.id|,|First Name|FROM|data/2024-01.csv|o|WHERE|note|<>|it's|AND|n|>=|-1.5|"
+	if strings.Join(got, "|") != expected {
+		t.Errorf("lexQuery() = %s, want %s", strings.Join(got, "|"), expected)
+	}
+	if tokens[3].kind != sqlIdent || tokens[10].kind != sqlString || tokens[14].kind != sqlNumber {
+		t.Errorf("lexQuery() assigned the wrong token kinds: %+v", tokens)
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	q, err := parseQuery("select distinct c.*, count(*) as n from orders.csv o left outer join customers.csv c on o.customer_id = c.id " +
+		"where total > 10 group by c.id having n > 1 order by 2 desc, c.name limit 5 offset 10;")
+	if err != nil {
+		t.Fatalf("parseQuery() error = %v", err)
+	}
+
+	switch {
+	case !q.distinct:
+		t.Error("DISTINCT was not parsed")
+	case len(q.items) != 2 || !q.items[0].star || q.items[0].table != "c" || q.items[1].alias != "n":
+		t.Errorf("unexpected select items: %+v", q.items)
+	case q.from != sqlTable{path: "orders.csv", alias: "o"}:
+		t.Errorf("unexpected FROM table: %+v", q.from)
+	case q.join == nil || !q.join.left || q.join.table != sqlTable{path: "customers.csv", alias: "c"}:
+		t.Errorf("unexpected JOIN: %+v", q.join)
+	case len(q.groupBy) != 1 || q.having == nil:
+		t.Error("GROUP BY or HAVING was not parsed")
+	case len(q.orderBy) != 2 || !q.orderBy[0].desc || q.orderBy[1].desc:
+		t.Errorf("unexpected ORDER BY: %+v", q.orderBy)
+	case q.limit != 5 || q.offset != 10:
+		t.Errorf("LIMIT %d OFFSET %d, want LIMIT 5 OFFSET 10", q.limit, q.offset)
+	}
+}
+
+func TestParseQueryTableAlias(t *testing.T) {
+	tests := map[string]string{
+		"SELECT * FROM data.csv":            "data",
+		"SELECT * FROM exports/q1.csv.gz":   "q1",
+		"SELECT * FROM 'my data.csv' AS md": "md",
+		"SELECT * FROM -":                   "-",
+	}
+	for query, alias := range tests {
+		q, err := parseQuery(query)
+		if err != nil {
+			t.Fatalf("parseQuery(%q) error = %v", query, err)
+		}
+		if q.from.alias != alias {
+			t.Errorf("parseQuery(%q) alias = %q, want %q", query, q.from.alias, alias)
+		}
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	tests := map[string]string{
+		"name FROM data.csv":                          "expected SELECT",
+		"SELECT name":                                 "expected FROM at end of query",
+		"SELECT name FROM data.csv WHERE":             "expected a column or value",
+		"SELECT name FROM data.csv LIMIT -1":          "LIMIT needs a non-negative integer",
+		"SELECT median(age) FROM data.csv":            "unknown function median",
+		"SELECT sum(*) FROM data.csv":                 "only COUNT accepts *",
+		"SELECT name FROM data.csv WHERE name = 'a":   "unterminated '",
+		"SELECT name FROM data.csv WHERE name LIKE x": "expected a quoted LIKE pattern",
+		"SELECT name FROM a.csv LEFT b.csv":           "expected JOIN",
+		"SELECT name FROM data.csv extra tokens":      "expected end of query",
+	}
+	for query, message := range tests {
+		_, err := parseQuery(query)
+		if err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("parseQuery(%q) error = %v, want it to contain %q", query, err, message)
+		}
+	}
+}
+
+func TestLikePattern(t *testing.T) {
+	pattern := likePattern("San_%.")
+	for text, want := range map[string]bool{"san .": true, "SanX Jose.": true, "San.": false, "San Jose": false} {
+		if got := pattern.MatchString(text); got != want {
+			t.Errorf("LIKE 'San_%%.' on %q = %v, want %v", text, got, want)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package converter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+var queryFiles = map[string]string{
+	"data.csv": "id,name,age,salary,department,active,join_date\n" +
+		"1,Ann,30,50000,Sales,true,2020-03-01\n" +
+		"2,Bob,40,70000,IT,false,2020-01-15\n" +
+		"3,Cid,,60000,Sales,true,2020-07-09\n" +
+		"4,Dee,35,90000,IT,true,2019-11-30\n" +
+		"5,Eve,28,45000,HR,true,2021-02-11\n",
+	"orders.csv":    "order_id,customer_id,total\n10,1,99.5\n11,2,10\n12,9,5\n13,1,20\n",
+	"customers.csv": "id,name\n1,Ann\n2,Bob\n",
+	"empty.csv":     "",
+}
+
+// runQuery runs a query over queryFiles with compact output in format
+func runQuery(query, format string) (string, error) {
+	options := DefaultOptions()
+	options.PrettyPrint = false
+	options.OutputFormat = format
+
+	open := func(path string) (io.Reader, ConversionOptions, error) {
+		data, ok := queryFiles[path]
+		if !ok {
+			return nil, options, fmt.Errorf("open %s: no such file", path)
+		}
+		return strings.NewReader(data), options, nil
+	}
+
+	var out bytes.Buffer
+	err := QueryCSV(query, open, &out, options)
+	return out.String(), err
+}
+
+func TestQueryCSV(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{
+			name:     "grouped average ordered by position",
+			query:    "SELECT department, AVG(salary) FROM data.csv WHERE active GROUP BY department ORDER BY 2 DESC LIMIT 10",
+			expected: `[{"avg_salary":90000,"department":"IT"},{"avg_salary":55000,"department":"Sales"},{"avg_salary":45000,"department":"HR"}]`,
+		},
+		{
+			name:     "filters and ordering with nulls first",
+			query:    "SELECT name, age FROM data.csv WHERE age IS NULL OR age BETWEEN 29 AND 40 ORDER BY age",
+			expected: `[{"age":null,"name":"Cid"},{"age":30,"name":"Ann"},{"age":35,"name":"Dee"},{"age":40,"name":"Bob"}]`,
+		},
+		{
+			name:     "like, in and not",
+			query:    "SELECT id FROM data.csv WHERE (name LIKE 'd%' OR department IN ('HR', 'Finance')) AND NOT id = 4",
+			expected: `[{"id":5}]`,
+		},
+		{
+			name:     "aliases in having and order by",
+			query:    "SELECT department AS d, COUNT(*) AS n, MAX(join_date) latest FROM data.csv GROUP BY d HAVING n > 1 ORDER BY latest DESC",
+			expected: `[{"d":"Sales","latest":"2020-07-09","n":2},{"d":"IT","latest":"2020-01-15","n":2}]`,
+		},
+		{
+			name:     "distinct with offset",
+			query:    "SELECT DISTINCT department FROM data.csv ORDER BY department LIMIT 2 OFFSET 1",
+			expected: `[{"department":"IT"},{"department":"Sales"}]`,
+		},
+		{
+			name:     "aggregates over no rows",
+			query:    "SELECT COUNT(*), SUM(salary) AS total FROM data.csv WHERE age > 100",
+			expected: `[{"count":0,"total":0}]`,
+		},
+		{
+			name:     "left join",
+			query:    "SELECT o.order_id, c.name FROM orders.csv o LEFT JOIN customers.csv c ON c.id = o.customer_id",
+			expected: `[{"name":"Ann","order_id":10},{"name":"Bob","order_id":11},{"name":null,"order_id":12},{"name":"Ann","order_id":13}]`,
+		},
+		{
+			name:     "inner join with colliding star columns",
+			query:    "SELECT * FROM customers.csv c JOIN data.csv d ON c.id = d.id WHERE age > 35",
+			expected: `[{"active":false,"age":40,"d.id":2,"d.name":"Bob","department":"IT","id":2,"join_date":"2020-01-15","name":"Bob","salary":70000}]`,
+		},
+		{
+			name:     "joined aggregate",
+			query:    "SELECT customers.name, SUM(total) AS spent FROM orders.csv JOIN customers.csv ON customer_id = customers.id GROUP BY customers.name ORDER BY spent DESC",
+			expected: `[{"name":"Ann","spent":119.5},{"name":"Bob","spent":10}]`,
+		},
+		{
+			name:     "empty file",
+			query:    "SELECT * FROM empty.csv",
+			expected: `[]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := runQuery(tt.query, "array")
+			if err != nil {
+				t.Fatalf("QueryCSV() error = %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("QueryCSV() = %s, want %s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestQueryCSVFormats(t *testing.T) {
+	query := "SELECT name, age FROM data.csv WHERE department = 'IT'"
+	tests := map[string]string{
+		"ndjson": "{\"age\":40,\"name\":\"Bob\"}\n{\"age\":35,\"name\":\"Dee\"}\n",
+		"object": `{"age":[40,35],"name":["Bob","Dee"]}`,
+	}
+	for format, expected := range tests {
+		result, err := runQuery(query, format)
+		if err != nil {
+			t.Fatalf("QueryCSV(%s) error = %v", format, err)
+		}
+		if result != expected {
+			t.Errorf("QueryCSV(%s) = %q, want %q", format, result, expected)
+		}
+	}
+}
+
+func TestQueryCSVStopsAtLimit(t *testing.T) {
+	options := DefaultOptions()
+	options.PrettyPrint = false
+	reads := 0
+	open := func(path string) (io.Reader, ConversionOptions, error) {
+		return &countingLines{remaining: 1000000, reads: &reads}, options, nil
+	}
+
+	var out bytes.Buffer
+	if err := QueryCSV("SELECT n FROM numbers.csv LIMIT 2", open, &out, options); err != nil {
+		t.Fatalf("QueryCSV() error = %v", err)
+	}
+	if out.String() != `[{"n":1},{"n":1}]` {
+		t.Errorf("QueryCSV() = %s", out.String())
+	}
+	if reads > 100 {
+		t.Errorf("QueryCSV() read %d chunks, want it to stop after the LIMIT", reads)
+	}
+}
+
+// countingLines produces a header and then endless rows of "1"
+type countingLines struct {
+	remaining int
+	reads     *int
+	header    bool
+}
+
+func (c *countingLines) Read(p []byte) (int, error) {
+	*c.reads++
+	if !c.header {
+		c.header = true
+		return copy(p, "n\n"), nil
+	}
+	if c.remaining == 0 {
+		return 0, io.EOF
+	}
+	n := 0
+	for n+2 <= len(p) && c.remaining > 0 {
+		n += copy(p[n:], "1\n")
+		c.remaining--
+	}
+	return n, nil
+}
+
+func TestQueryCSVErrors(t *testing.T) {
+	tests := map[string]string{
+		"SELECT nope FROM data.csv":                                         `unknown column "nope"`,
+		"SELECT name FROM data.csv WHERE COUNT(*) > 1":                      "COUNT is not allowed in WHERE",
+		"SELECT SUM(MAX(age)) FROM data.csv":                                "MAX is not allowed in another aggregate",
+		"SELECT name FROM data.csv ORDER BY 3":                              "ORDER BY position 3 is not in the select list",
+		"SELECT name FROM data.csv HAVING age > 1":                          "HAVING requires GROUP BY",
+		"SELECT name FROM missing.csv":                                      "no such file",
+		"SELECT name FROM orders.csv JOIN customers.csv ON customer_id > 1": "must compare a column of each table",
+		"SELECT name FROM customers.csv JOIN customers.csv ON id = id":      "give one an alias",
+		"SELECT name FROM data.csv d JOIN customers.csv c ON d.id = c.id":   `ambiguous column "name"`,
+		"SELECT x.* FROM data.csv":                                          `unknown table "x"`,
+	}
+	for query, message := range tests {
+		_, err := runQuery(query, "array")
+		if err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("QueryCSV(%q) error = %v, want it to contain %q", query, err, message)
+		}
+	}
+}
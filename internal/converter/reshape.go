@@ -0,0 +1,198 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Default column names for the long form of pivot and unpivot
+const (
+	DefaultVarName   = "variable"
+	DefaultValueName = "value"
+)
+
+// unpivoter turns each wide record into one long record per non-identifier
+// column: the identifier columns, the column name under varName and its value
+// under valueName. Columns are unpivoted in header order.
+type unpivoter struct {
+	next      recordWriter
+	ids       []string
+	columns   []string
+	varName   string
+	valueName string
+}
+
+func newUnpivoter(next recordWriter, headers []string, options ConversionOptions) (*unpivoter, error) {
+	if err := checkColumns(headers, "identifier", options.IDColumns...); err != nil {
+		return nil, err
+	}
+	isID := make(map[string]bool)
+	for _, id := range options.IDColumns {
+		isID[id] = true
+	}
+	var columns []string
+	for _, header := range headers {
+		if !isID[header] {
+			columns = append(columns, header)
+		}
+	}
+	return &unpivoter{
+		next:      next,
+		ids:       options.IDColumns,
+		columns:   columns,
+		varName:   reshapeName(options.VarName, DefaultVarName),
+		valueName: reshapeName(options.ValueName, DefaultValueName),
+	}, nil
+}
+
+func (u *unpivoter) write(record map[string]interface{}) error {
+	for _, column := range u.columns {
+		long := make(map[string]interface{}, len(u.ids)+2)
+		for _, id := range u.ids {
+			long[id] = record[id]
+		}
+		long[u.varName] = column
+		long[u.valueName] = record[column]
+		if err := u.next.write(long); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u *unpivoter) close() error {
+	return u.next.close()
+}
+
+// pivoter turns long records into wide ones: records sharing the identifier
+// columns become one record with a column per distinct varName value holding
+// the valueName values, combined with the aggregate function when several
+// records land in the same cell. Missing cells are null. Pivoting needs every
+// record, so output is written when the input ends.
+type pivoter struct {
+	next      recordWriter
+	ids       []string
+	varName   string
+	valueName string
+	function  string
+
+	order   []string
+	rows    map[string]*pivotRow
+	columns []string
+	seen    map[string]bool
+}
+
+// pivotRow is one output record of a pivot while it is being built
+type pivotRow struct {
+	ids   map[string]interface{}
+	cells map[string]*aggregator
+}
+
+func newPivoter(next recordWriter, headers []string, options ConversionOptions) (*pivoter, error) {
+	p := &pivoter{
+		next:      next,
+		ids:       options.IDColumns,
+		varName:   reshapeName(options.VarName, DefaultVarName),
+		valueName: reshapeName(options.ValueName, DefaultValueName),
+		function:  options.PivotAggregate,
+		rows:      make(map[string]*pivotRow),
+		seen:      make(map[string]bool),
+	}
+	if p.function == "" {
+		p.function = "first"
+	}
+	if _, err := newAggregator(p.function); err != nil {
+		return nil, err
+	}
+	if err := checkColumns(headers, "identifier", p.ids...); err != nil {
+		return nil, err
+	}
+	if err := checkColumns(headers, "variable", p.varName); err != nil {
+		return nil, err
+	}
+	if err := checkColumns(headers, "value", p.valueName); err != nil {
+		return nil, err
+	}
+
+	// Without explicit identifiers every other column identifies a row
+	if len(p.ids) == 0 {
+		for _, header := range headers {
+			if header != p.varName && header != p.valueName {
+				p.ids = append(p.ids, header)
+			}
+		}
+	}
+	return p, nil
+}
+
+func (p *pivoter) write(record map[string]interface{}) error {
+	idValues := make([]interface{}, len(p.ids))
+	for i, id := range p.ids {
+		idValues[i] = record[id]
+	}
+	keyJSON, err := json.Marshal(idValues)
+	if err != nil {
+		return err
+	}
+	key := string(keyJSON)
+
+	row, ok := p.rows[key]
+	if !ok {
+		row = &pivotRow{ids: make(map[string]interface{}, len(p.ids)), cells: make(map[string]*aggregator)}
+		for _, id := range p.ids {
+			row.ids[id] = record[id]
+		}
+		p.rows[key] = row
+		p.order = append(p.order, key)
+	}
+
+	column := record[p.varName]
+	if column == nil {
+		return nil
+	}
+	name, ok := column.(string)
+	if !ok {
+		name = fmt.Sprint(column)
+	}
+	if !p.seen[name] {
+		p.seen[name] = true
+		p.columns = append(p.columns, name)
+	}
+
+	cell, ok := row.cells[name]
+	if !ok {
+		cell, _ = newAggregator(p.function)
+		row.cells[name] = cell
+	}
+	cell.add(record[p.valueName])
+	return nil
+}
+
+func (p *pivoter) close() error {
+	for _, key := range p.order {
+		row := p.rows[key]
+		wide := make(map[string]interface{}, len(p.ids)+len(p.columns))
+		for id, value := range row.ids {
+			wide[id] = value
+		}
+		for _, column := range p.columns {
+			if cell, ok := row.cells[column]; ok {
+				wide[column] = cell.result()
+			} else {
+				wide[column] = nil
+			}
+		}
+		if err := p.next.write(wide); err != nil {
+			return err
+		}
+	}
+	return p.next.close()
+}
+
+// reshapeName returns name, or fallback when it is empty
+func reshapeName(name, fallback string) string {
+	if name == "" {
+		return fallback
+	}
+	return name
+}
@@ -0,0 +1,157 @@
+package converter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestReshape(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		setup    func(*ConversionOptions)
+		expected string
+	}{
+		{
+			name:  "Unpivot wide months",
+			input: "region,jan,feb\nNorth,10,12\nSouth,7,\n",
+			setup: func(o *ConversionOptions) {
+				o.Reshape, o.IDColumns, o.VarName, o.ValueName = "unpivot", []string{"region"}, "month", "amount"
+			},
+			expected: `[{"amount":10,"month":"jan","region":"North"},{"amount":12,"month":"feb","region":"North"},` +
+				`{"amount":7,"month":"jan","region":"South"},{"amount":null,"month":"feb","region":"South"}]`,
+		},
+		{
+			name:  "Pivot long rows with sum for collisions",
+			input: "region,month,amount\nNorth,jan,10\nNorth,feb,12\nNorth,jan,5\nSouth,feb,7\n",
+			setup: func(o *ConversionOptions) {
+				o.Reshape, o.VarName, o.ValueName, o.PivotAggregate = "pivot", "month", "amount", "sum"
+			},
+			expected: `[{"feb":12,"jan":15,"region":"North"},{"feb":7,"jan":null,"region":"South"}]`,
+		},
+		{
+			name:  "Pivot keeps the first value by default",
+			input: "id,variable,value\n1,color,red\n1,color,blue\n",
+			setup: func(o *ConversionOptions) {
+				o.Reshape = "pivot"
+			},
+			expected: `[{"color":"red","id":1}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := DefaultOptions()
+			options.PrettyPrint = false
+			tt.setup(&options)
+
+			result, err := ConvertCSVToJSON(strings.NewReader(tt.input), options)
+			if err != nil {
+				t.Fatalf("ConvertCSVToJSON failed: %v", err)
+			}
+			if string(result) != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, result)
+			}
+
+			var streamed bytes.Buffer
+			if err := ConvertCSVToJSONStream(strings.NewReader(tt.input), &streamed, options); err != nil {
+				t.Fatalf("ConvertCSVToJSONStream failed: %v", err)
+			}
+			if streamed.String() != tt.expected {
+				t.Errorf("stream: expected %s, got %s", tt.expected, streamed.String())
+			}
+		})
+	}
+}
+
+func TestReshapeUnknownColumns(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		setup func(*ConversionOptions)
+		err   string
+	}{
+		{
+			name:  "Unpivot identifier",
+			input: "region,jan,feb\nNorth,10,12\n",
+			setup: func(o *ConversionOptions) { o.Reshape, o.IDColumns = "unpivot", []string{"nope"} },
+			err:   `unknown identifier column "nope"`,
+		},
+		{
+			name:  "Pivot identifier",
+			input: "region,month,amount\nNorth,jan,10\n",
+			setup: func(o *ConversionOptions) {
+				o.Reshape, o.IDColumns, o.VarName, o.ValueName = "pivot", []string{"regoin"}, "month", "amount"
+			},
+			err: `unknown identifier column "regoin"`,
+		},
+		{
+			name:  "Pivot variable",
+			input: "region,month,amount\nNorth,jan,10\n",
+			setup: func(o *ConversionOptions) { o.Reshape, o.VarName, o.ValueName = "pivot", "mnth", "amount" },
+			err:   `unknown variable column "mnth"`,
+		},
+		{
+			name:  "Pivot value",
+			input: "region,month,amount\nNorth,jan,10\n",
+			setup: func(o *ConversionOptions) { o.Reshape, o.VarName = "pivot", "month" },
+			err:   `unknown value column "value"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := DefaultOptions()
+			tt.setup(&options)
+
+			_, err := ConvertCSVToJSON(strings.NewReader(tt.input), options)
+			var streamed bytes.Buffer
+			streamErr := ConvertCSVToJSONStream(strings.NewReader(tt.input), &streamed, options)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected error containing %q, got %v", tt.err, err)
+			}
+			if streamErr == nil || !strings.Contains(streamErr.Error(), tt.err) {
+				t.Errorf("stream: expected error containing %q, got %v", tt.err, streamErr)
+			}
+		})
+	}
+}
+
+func TestAggregator(t *testing.T) {
+	values := []interface{}{int64(3), nil, 1.5, int64(-1)}
+	expected := map[string]interface{}{
+		"count": int64(3),
+		"sum":   3.5,
+		"avg":   3.5 / 3,
+		"min":   int64(-1),
+		"max":   int64(3),
+		"first": int64(3),
+		"last":  int64(-1),
+	}
+
+	for function, want := range expected {
+		a, err := newAggregator(function)
+		if err != nil {
+			t.Fatalf("newAggregator(%q) failed: %v", function, err)
+		}
+		for _, value := range values {
+			a.add(value)
+		}
+		if got := a.result(); got != want {
+			t.Errorf("%s: expected %v (%T), got %v (%T)", function, want, want, got, got)
+		}
+	}
+
+	latest, _ := newAggregator("max")
+	for _, date := range []interface{}{"2023-11-30", "2024-01-02", "2023-12-31"} {
+		latest.add(date)
+	}
+	if got := latest.result(); got != "2024-01-02" {
+		t.Errorf("max date: expected 2024-01-02, got %v", got)
+	}
+
+	if _, err := newAggregator("median"); err == nil {
+		t.Error("expected an error for an unknown function")
+	}
+}
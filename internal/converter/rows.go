@@ -0,0 +1,56 @@
+package converter
+
+import (
+	"bufio"
+	"io"
+)
+
+// skipLines discards the first n physical lines of reader, such as the report
+// title lines that precede the header in bank and ERP exports
+func skipLines(reader io.Reader, n int) io.Reader {
+	if n <= 0 {
+		return reader
+	}
+	buffered := bufio.NewReader(reader)
+	for skipped := 0; skipped < n; {
+		switch _, err := buffered.ReadSlice('\n'); err {
+		case nil:
+			skipped++
+		case bufio.ErrBufferFull:
+			// Keep reading the rest of an overlong line
+		default:
+			return buffered
+		}
+	}
+	return buffered
+}
+
+// rowTrimmer drops the records above the header row and holds back the last
+// footer records so that totals and trailers are never returned
+type rowTrimmer struct {
+	reader  recordReader
+	skip    int
+	footer  int
+	pending [][]string
+}
+
+func (t *rowTrimmer) Read() ([]string, error) {
+	for ; t.skip > 0; t.skip-- {
+		if _, err := t.reader.Read(); err != nil {
+			return nil, err
+		}
+	}
+
+	for len(t.pending) <= t.footer {
+		record, err := t.reader.Read()
+		if err != nil {
+			// At EOF the pending records are the footer
+			return nil, err
+		}
+		t.pending = append(t.pending, record)
+	}
+
+	record := t.pending[0]
+	t.pending = t.pending[1:]
+	return record, nil
+}
@@ -0,0 +1,130 @@
+package converter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPreambleAndFooterOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		setup    func(*ConversionOptions)
+		expected string
+	}{
+		{
+			name:     "Skip title lines and total row",
+			input:    "Account statement\nPeriod: March\n\ndate,amount\n2024-03-01,10\n2024-03-02,20\nTotal,30\n",
+			setup:    func(o *ConversionOptions) { o.SkipRows = 3; o.SkipFooter = 1 },
+			expected: `[{"amount":10,"date":"2024-03-01"},{"amount":20,"date":"2024-03-02"}]`,
+		},
+		{
+			name:     "Header row with ragged title rows",
+			input:    "Sales report\nRegion: North,Generated 2024-04-01\nname,qty,price\nwidget,2,9.5\n",
+			setup:    func(o *ConversionOptions) { o.HeaderRow = 3 },
+			expected: `[{"name":"widget","price":9.5,"qty":2}]`,
+		},
+		{
+			name:     "Single character comment",
+			input:    "# exported by ERP\nname,qty\n# in stock\nbolt,4\n",
+			setup:    func(o *ConversionOptions) { o.Comment = "#" },
+			expected: `[{"name":"bolt","qty":4}]`,
+		},
+		{
+			name:     "Multi-character comment",
+			input:    "// generated\nname,qty\nbolt,4\n/x,5\n",
+			setup:    func(o *ConversionOptions) { o.Comment = "//" },
+			expected: `[{"name":"bolt","qty":4},{"name":"/x","qty":5}]`,
+		},
+		{
+			name:     "Generated column names after skipped rows",
+			input:    "Report\na,1,true\nb,2,false\nrows: 2\n",
+			setup:    func(o *ConversionOptions) { o.HasHeader = false; o.HeaderRow = 2; o.SkipFooter = 1 },
+			expected: `[{"column_1":"a","column_2":1,"column_3":true},{"column_1":"b","column_2":2,"column_3":false}]`,
+		},
+		{
+			name:     "Footer longer than the data",
+			input:    "name\nx\n",
+			setup:    func(o *ConversionOptions) { o.SkipFooter = 5 },
+			expected: `[]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := DefaultOptions()
+			options.PrettyPrint = false
+			tt.setup(&options)
+
+			result, err := ConvertCSVToJSON(strings.NewReader(tt.input), options)
+			if err != nil {
+				t.Fatalf("ConvertCSVToJSON failed: %v", err)
+			}
+			if string(result) != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, result)
+			}
+
+			var streamed bytes.Buffer
+			if err := ConvertCSVToJSONStream(strings.NewReader(tt.input), &streamed, options); err != nil {
+				t.Fatalf("ConvertCSVToJSONStream failed: %v", err)
+			}
+			if streamed.String() != tt.expected {
+				t.Errorf("stream: expected %s, got %s", tt.expected, streamed.String())
+			}
+		})
+	}
+}
+
+func TestSkipRowsWithSepDirective(t *testing.T) {
+	options := DefaultOptions()
+	options.PrettyPrint = false
+	options.SkipRows = 1
+
+	result, err := ConvertCSVToJSON(strings.NewReader("sep=;\nTitle\nname;qty\nbolt;4\n"), options)
+	if err != nil {
+		t.Fatalf("ConvertCSVToJSON failed: %v", err)
+	}
+	if expected := `[{"name":"bolt","qty":4}]`; string(result) != expected {
+		t.Errorf("expected %s, got %s", expected, result)
+	}
+}
+
+func TestCombineHeaderRows(t *testing.T) {
+	rows := [][]string{
+		{"", "2024", "", "", "", "2025", ""},
+		{"Region", "Q1", "", "Q2", "", "Q1", ""},
+		{"", "Revenue", "Cost", "Revenue", "Cost", "Revenue", "Cost"},
+	}
+	expected := []string{"Region", "2024.Q1.Revenue", "2024.Q1.Cost", "2024.Q2.Revenue", "2024.Q2.Cost", "2025.Q1.Revenue", "2025.Q1.Cost"}
+
+	got := combineHeaderRows(rows)
+	if strings.Join(got, "|") != strings.Join(expected, "|") {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestMultiRowHeaders(t *testing.T) {
+	input := "Store,Q1,,Q2\n,Revenue,Cost,Revenue\nOslo,100,60,120\n"
+	expected := `[{"Q1.Cost":60,"Q1.Revenue":100,"Q2.Revenue":120,"Store":"Oslo"}]`
+
+	options := DefaultOptions()
+	options.PrettyPrint = false
+	options.HeaderRows = 2
+
+	result, err := ConvertCSVToJSON(strings.NewReader(input), options)
+	if err != nil {
+		t.Fatalf("ConvertCSVToJSON failed: %v", err)
+	}
+	if string(result) != expected {
+		t.Errorf("expected %s, got %s", expected, result)
+	}
+
+	var streamed bytes.Buffer
+	if err := ConvertCSVToJSONStream(strings.NewReader(input), &streamed, options); err != nil {
+		t.Fatalf("ConvertCSVToJSONStream failed: %v", err)
+	}
+	if streamed.String() != expected {
+		t.Errorf("stream: expected %s, got %s", expected, streamed.String())
+	}
+}
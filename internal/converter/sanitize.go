@@ -0,0 +1,52 @@
+package converter
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// isFormulaCell reports whether a cell would be interpreted as a formula when
+// the CSV is opened in a spreadsheet: it starts with '=', '+', '-' or '@' and
+// is not simply a signed number such as "-12.5"
+func isFormulaCell(cell string) bool {
+	if cell == "" {
+		return false
+	}
+	switch cell[0] {
+	case '=', '+', '-', '@':
+	default:
+		return false
+	}
+	_, err := strconv.ParseFloat(cell, 64)
+	return err != nil
+}
+
+// SanitizeFormula prefixes a cell that would run as a spreadsheet formula with
+// a single quote so that it is displayed as text
+func SanitizeFormula(cell string) string {
+	if isFormulaCell(cell) {
+		return "'" + cell
+	}
+	return cell
+}
+
+// formulaDetector passes records through, reporting cells that look like formulas
+type formulaDetector struct {
+	reader recordReader
+	warn   func(message string)
+	record int
+}
+
+func (d *formulaDetector) Read() ([]string, error) {
+	record, err := d.reader.Read()
+	if err != nil {
+		return record, err
+	}
+	d.record++
+	for i, cell := range record {
+		if _, text := excelTextValue(cell); !text && isFormulaCell(cell) {
+			d.warn(fmt.Sprintf("record %d, column %d: cell %q may be run as a spreadsheet formula", d.record, i+1, cell))
+		}
+	}
+	return record, nil
+}
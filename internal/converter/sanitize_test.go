@@ -0,0 +1,43 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitizeFormula(t *testing.T) {
+	tests := map[string]string{
+		"=1+2":            "'=1+2",
+		"+cmd|' /C calc'": "'+cmd|' /C calc'",
+		"-2+3":            "'-2+3",
+		"@SUM(A1)":        "'@SUM(A1)",
+		"-12.5":           "-12.5",
+		"+44":             "+44",
+		"plain":           "plain",
+		"":                "",
+	}
+	for input, expected := range tests {
+		if got := SanitizeFormula(input); got != expected {
+			t.Errorf("SanitizeFormula(%q) = %q, want %q", input, got, expected)
+		}
+	}
+}
+
+func TestDetectFormulasWarns(t *testing.T) {
+	var warnings []string
+	options := DefaultOptions()
+	options.DetectFormulas = true
+	options.OnWarning = func(message string) { warnings = append(warnings, message) }
+
+	csvData := "name,note\nAnn,@SUM(1+1)\nBob,-3\n"
+	result, err := ConvertCSVToJSON(strings.NewReader(csvData), options)
+	if err != nil {
+		t.Fatalf("ConvertCSVToJSON failed: %v", err)
+	}
+	if !strings.Contains(string(result), `"@SUM(1+1)"`) {
+		t.Errorf("cell value should be unchanged, got %s", result)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "record 2, column 2") {
+		t.Errorf("unexpected warnings: %q", warnings)
+	}
+}
@@ -0,0 +1,138 @@
+package converter
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// ConvertCSVToJSONStream converts CSV data and writes the JSON to writer as
+// rows are read, so memory use does not grow with the input. The array and
+// ndjson formats are encoded record by record (the keyed format is written
+// once all rows are indexed) and produce the same bytes as
+// ConvertCSVToJSON; the column-oriented object format, multi-table input and
+// the keyvalue and transpose layouts need every row and are buffered.
+func ConvertCSVToJSONStream(reader io.Reader, writer io.Writer, options ConversionOptions) error {
+	if options.OutputFormat == "object" || options.MultiTable || (options.Layout != "" && options.Layout != "table") {
+		jsonData, err := ConvertCSVToJSON(reader, options)
+		if err != nil {
+			return err
+		}
+		_, err = writer.Write(jsonData)
+		return err
+	}
+
+	csvReader, err := newRecordReader(reader, options)
+	if err != nil {
+		return err
+	}
+
+	ultraOptions := DefaultUltraOptimizedOptions()
+	ultraOptions.ConversionOptions = options
+	out := bufio.NewWriter(writer)
+
+	headers, pending, err := readHeader(csvReader, options)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	pipeline, pipelineErr := newRecordPipeline(out, headers, options)
+	if pipelineErr != nil {
+		return pipelineErr
+	}
+	if err == io.EOF {
+		if err := pipeline.close(); err != nil {
+			return err
+		}
+		return out.Flush()
+	}
+	for {
+		var row []string
+		if len(pending) > 0 {
+			row, pending = pending[0], pending[1:]
+		} else {
+			row, err = csvReader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read CSV: %w", err)
+			}
+		}
+
+		if err := pipeline.write(processRowUltra(row, headers, ultraOptions)); err != nil {
+			return err
+		}
+	}
+
+	if err := pipeline.close(); err != nil {
+		return err
+	}
+	return out.Flush()
+}
+
+// recordEncoder writes records one at a time, either as newline-delimited JSON
+// or as a JSON array matching the layout of json.Marshal and json.MarshalIndent
+// with a two-space indent
+type recordEncoder struct {
+	out    *bufio.Writer
+	pretty bool
+	ndjson bool
+	count  int
+}
+
+func newRecordEncoder(out *bufio.Writer, options ConversionOptions) *recordEncoder {
+	ndjson := options.OutputFormat == "ndjson"
+	return &recordEncoder{out: out, pretty: options.PrettyPrint && !ndjson, ndjson: ndjson}
+}
+
+// write encodes one record, making recordEncoder the last recordWriter of a pipeline
+func (e *recordEncoder) write(record map[string]interface{}) error {
+	return e.encode(record)
+}
+
+// encode writes one record
+func (e *recordEncoder) encode(v interface{}) error {
+	var data []byte
+	var err error
+	if e.pretty {
+		data, err = json.MarshalIndent(v, "  ", "  ")
+	} else {
+		data, err = json.Marshal(v)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case e.ndjson:
+		e.count++
+		e.out.Write(data)
+		return e.out.WriteByte('\n')
+	case e.count == 0 && e.pretty:
+		e.out.WriteString("[\n  ")
+	case e.count == 0:
+		e.out.WriteByte('[')
+	case e.pretty:
+		e.out.WriteString(",\n  ")
+	default:
+		e.out.WriteByte(',')
+	}
+	e.count++
+	_, err = e.out.Write(data)
+	return err
+}
+
+// close terminates the output, writing "[]" for an empty array
+func (e *recordEncoder) close() error {
+	switch {
+	case e.ndjson:
+	case e.count == 0:
+		e.out.WriteString("[]")
+	case e.pretty:
+		e.out.WriteString("\n]")
+	default:
+		e.out.WriteByte(']')
+	}
+	return nil
+}
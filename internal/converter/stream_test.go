@@ -0,0 +1,53 @@
+package converter
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestConvertCSVToJSONStreamMatchesBuffered(t *testing.T) {
+	var input strings.Builder
+	input.WriteString("id,name,score\n")
+	for i := 1; i <= 200; i++ {
+		fmt.Fprintf(&input, "%d,user%d,%d.5\n", i, i, i)
+	}
+
+	for _, format := range []string{"array", "object", "ndjson"} {
+		for _, pretty := range []bool{true, false} {
+			for _, hasHeader := range []bool{true, false} {
+				options := DefaultOptions()
+				options.OutputFormat = format
+				options.PrettyPrint = pretty
+				options.HasHeader = hasHeader
+
+				expected, err := ConvertCSVToJSON(strings.NewReader(input.String()), options)
+				if err != nil {
+					t.Fatalf("ConvertCSVToJSON() error = %v", err)
+				}
+
+				var streamed bytes.Buffer
+				if err := ConvertCSVToJSONStream(strings.NewReader(input.String()), &streamed, options); err != nil {
+					t.Fatalf("ConvertCSVToJSONStream() error = %v", err)
+				}
+
+				if streamed.String() != string(expected) {
+					t.Errorf("format=%s pretty=%v header=%v: streamed output differs from buffered output", format, pretty, hasHeader)
+				}
+			}
+		}
+	}
+}
+
+func TestConvertCSVToJSONStreamEmpty(t *testing.T) {
+	for _, input := range []string{"", "a,b\n"} {
+		var out bytes.Buffer
+		if err := ConvertCSVToJSONStream(strings.NewReader(input), &out, DefaultOptions()); err != nil {
+			t.Fatalf("ConvertCSVToJSONStream() error = %v", err)
+		}
+		if out.String() != "[]" {
+			t.Errorf("ConvertCSVToJSONStream(%q) = %q, want []", input, out.String())
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package converter
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// JSONToCSVOptions configures ConvertJSONToCSV
+type JSONToCSVOptions struct {
+	Delimiter    rune
+	ArrayJoiner  string // joins the elements of scalar arrays into one cell
+	KeySeparator string // joins nested object keys, e.g. "address.city"
+	UseCRLF      bool
+	// SanitizeFormulas prefixes cells starting with '=', '+', '-' or '@' with a
+	// single quote so spreadsheets do not run them as formulas
+	SanitizeFormulas bool
+}
+
+// DefaultJSONToCSVOptions returns default JSON to CSV options
+func DefaultJSONToCSVOptions() JSONToCSVOptions {
+	return JSONToCSVOptions{
+		Delimiter:    ',',
+		ArrayJoiner:  ";",
+		KeySeparator: ".",
+	}
+}
+
+// orderedObject is a decoded JSON object that remembers its key order
+type orderedObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// ConvertJSONToCSV converts JSON back to CSV. The input may be an array of
+// objects, the column-oriented "object" format produced by ConvertCSVToJSON
+// (an object whose values are equal-length arrays) or NDJSON. The header is the
+// union of keys in first-seen order; nested objects are flattened into dotted
+// keys and arrays of scalars are joined with ArrayJoiner.
+func ConvertJSONToCSV(reader io.Reader, writer io.Writer, options JSONToCSVOptions) error {
+	records, err := decodeJSONRecords(reader)
+	if err != nil {
+		return err
+	}
+
+	var header []string
+	seen := make(map[string]bool)
+	rows := make([]map[string]string, 0, len(records))
+
+	for _, record := range records {
+		row := make(map[string]string)
+		var keys []string
+		flattenObject(record, "", options, row, &keys)
+		for _, key := range keys {
+			if !seen[key] {
+				seen[key] = true
+				header = append(header, key)
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	out := bufio.NewWriter(writer)
+	csvWriter := csv.NewWriter(out)
+	if options.Delimiter != 0 {
+		csvWriter.Comma = options.Delimiter
+	}
+	csvWriter.UseCRLF = options.UseCRLF
+
+	if len(header) > 0 {
+		line := header
+		if options.SanitizeFormulas {
+			// Keys come from the input too, so they are as untrusted as the values
+			line = make([]string, len(header))
+			for i, key := range header {
+				line[i] = SanitizeFormula(key)
+			}
+		}
+		if err := csvWriter.Write(line); err != nil {
+			return err
+		}
+	}
+	line := make([]string, len(header))
+	for _, row := range rows {
+		for i, key := range header {
+			line[i] = row[key]
+			if options.SanitizeFormulas {
+				line[i] = SanitizeFormula(line[i])
+			}
+		}
+		if err := csvWriter.Write(line); err != nil {
+			return err
+		}
+	}
+
+	csvWriter.Flush()
+	if err := csvWriter.Error(); err != nil {
+		return err
+	}
+	return out.Flush()
+}
+
+// decodeJSONRecords reads every record from an array, columnar object or NDJSON input
+func decodeJSONRecords(reader io.Reader) ([]*orderedObject, error) {
+	decoder := json.NewDecoder(reader)
+	decoder.UseNumber()
+
+	var values []interface{}
+	for {
+		value, err := decodeOrdered(decoder)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		values = append(values, value)
+	}
+
+	if len(values) == 1 {
+		switch v := values[0].(type) {
+		case []interface{}:
+			values = v
+		case *orderedObject:
+			if columns, ok := columnarRecords(v); ok {
+				return columns, nil
+			}
+		}
+	}
+
+	records := make([]*orderedObject, 0, len(values))
+	for i, value := range values {
+		object, ok := value.(*orderedObject)
+		if !ok {
+			return nil, fmt.Errorf("record %d is not a JSON object", i+1)
+		}
+		records = append(records, object)
+	}
+	return records, nil
+}
+
+// columnarRecords turns {"name": ["a", "b"], "age": [1, 2]} into row records.
+// It reports false unless every value is an array and they share one length.
+func columnarRecords(object *orderedObject) ([]*orderedObject, bool) {
+	length := -1
+	for _, key := range object.keys {
+		column, ok := object.values[key].([]interface{})
+		if !ok || (length >= 0 && len(column) != length) {
+			return nil, false
+		}
+		length = len(column)
+	}
+	if length < 0 {
+		return nil, false
+	}
+
+	records := make([]*orderedObject, length)
+	for i := range records {
+		record := &orderedObject{keys: object.keys, values: make(map[string]interface{}, len(object.keys))}
+		for _, key := range object.keys {
+			record.values[key] = object.values[key].([]interface{})[i]
+		}
+		records[i] = record
+	}
+	return records, true
+}
+
+// decodeOrdered decodes the next JSON value, keeping object keys in document order
+func decodeOrdered(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := token.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			object := &orderedObject{values: make(map[string]interface{})}
+			for decoder.More() {
+				keyToken, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				key := keyToken.(string)
+				value, err := decodeOrdered(decoder)
+				if err != nil {
+					return nil, err
+				}
+				if _, exists := object.values[key]; !exists {
+					object.keys = append(object.keys, key)
+				}
+				object.values[key] = value
+			}
+			_, err := decoder.Token() // closing brace
+			return object, err
+		case '[':
+			array := []interface{}{}
+			for decoder.More() {
+				value, err := decodeOrdered(decoder)
+				if err != nil {
+					return nil, err
+				}
+				array = append(array, value)
+			}
+			_, err := decoder.Token() // closing bracket
+			return array, err
+		}
+		return nil, fmt.Errorf("unexpected delimiter %v", t)
+	}
+	return token, nil
+}
+
+// flattenObject writes the cells of object into row, recording keys in order
+func flattenObject(object *orderedObject, prefix string, options JSONToCSVOptions, row map[string]string, keys *[]string) {
+	for _, key := range object.keys {
+		name := key
+		if prefix != "" {
+			name = prefix + options.KeySeparator + key
+		}
+
+		if nested, ok := object.values[key].(*orderedObject); ok && len(nested.keys) > 0 {
+			flattenObject(nested, name, options, row, keys)
+			continue
+		}
+		if _, exists := row[name]; !exists {
+			*keys = append(*keys, name)
+		}
+		row[name] = formatCell(object.values[key], options)
+	}
+}
+
+// formatCell renders a JSON value as CSV cell text
+func formatCell(value interface{}, options JSONToCSVOptions) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	case []interface{}:
+		parts := make([]string, len(v))
+		for i, element := range v {
+			switch element.(type) {
+			case []interface{}, *orderedObject:
+				parts[i] = marshalOrdered(element)
+			default:
+				parts[i] = formatCell(element, options)
+			}
+		}
+		return strings.Join(parts, options.ArrayJoiner)
+	case *orderedObject:
+		return marshalOrdered(v)
+	}
+	return fmt.Sprint(value)
+}
+
+// marshalOrdered encodes a decoded value back to compact JSON, preserving key order
+func marshalOrdered(value interface{}) string {
+	var buf bytes.Buffer
+	writeOrdered(&buf, value)
+	return buf.String()
+}
+
+func writeOrdered(buf *bytes.Buffer, value interface{}) {
+	switch v := value.(type) {
+	case *orderedObject:
+		buf.WriteByte('{')
+		for i, key := range v.keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			keyJSON, _ := json.Marshal(key)
+			buf.Write(keyJSON)
+			buf.WriteByte(':')
+			writeOrdered(buf, v.values[key])
+		}
+		buf.WriteByte('}')
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, element := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeOrdered(buf, element)
+		}
+		buf.WriteByte(']')
+	default:
+		data, _ := json.Marshal(v)
+		buf.Write(data)
+	}
+}
@@ -0,0 +1,76 @@
+package converter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestConvertJSONToCSV(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		options  JSONToCSVOptions
+		expected string
+	}{
+		{
+			name:  "Array of objects with nested fields",
+			input: `[{"id":1,"name":"Ann","address":{"city":"Oslo","zip":"0150"},"tags":["a","b"]},{"id":2,"name":"Bob, Jr.","email":null,"active":true}]`,
+			expected: "id,name,address.city,address.zip,tags,email,active\n" +
+				"1,Ann,Oslo,0150,a;b,,\n" +
+				"2,\"Bob, Jr.\",,,,,true\n",
+		},
+		{
+			name:     "Columnar object format",
+			input:    `{"name":["John","Jane"],"age":[30,25.5]}`,
+			expected: "name,age\nJohn,30\nJane,25.5\n",
+		},
+		{
+			name:     "NDJSON",
+			input:    "{\"b\":1,\"a\":\"x\\\"y\"}\n{\"c\":[1,{\"d\":2}]}\n",
+			options:  JSONToCSVOptions{Delimiter: ';', ArrayJoiner: "|", KeySeparator: "_"},
+			expected: "b;a;c\n1;\"x\"\"y\";\n;;\"1|{\"\"d\"\":2}\"\n",
+		},
+		{
+			name:     "Sanitized formulas",
+			input:    `[{"a":"=SUM(A1:A2)","b":-5,"c":"@cmd","d":"safe"}]`,
+			options:  JSONToCSVOptions{Delimiter: ',', ArrayJoiner: ";", KeySeparator: ".", SanitizeFormulas: true},
+			expected: "a,b,c,d\n'=SUM(A1:A2),-5,'@cmd,safe\n",
+		},
+		{
+			name:     "Sanitized header",
+			input:    `[{"=cmd|' /C calc'!A0":1,"+x":2,"id":3}]`,
+			options:  JSONToCSVOptions{Delimiter: ',', ArrayJoiner: ";", KeySeparator: ".", SanitizeFormulas: true},
+			expected: "'=cmd|' /C calc'!A0,'+x,id\n1,2,3\n",
+		},
+		{
+			name:     "Empty array",
+			input:    `[]`,
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := tt.options
+			if options.Delimiter == 0 {
+				options = DefaultJSONToCSVOptions()
+			}
+
+			var out bytes.Buffer
+			if err := ConvertJSONToCSV(strings.NewReader(tt.input), &out, options); err != nil {
+				t.Fatalf("ConvertJSONToCSV() error = %v", err)
+			}
+			if out.String() != tt.expected {
+				t.Errorf("ConvertJSONToCSV() = %q, want %q", out.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestConvertJSONToCSVRejectsScalars(t *testing.T) {
+	var out bytes.Buffer
+	if err := ConvertJSONToCSV(strings.NewReader(`[1,2]`), &out, DefaultJSONToCSVOptions()); err == nil {
+		t.Error("ConvertJSONToCSV() expected error for non-object records")
+	}
+}
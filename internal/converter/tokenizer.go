@@ -0,0 +1,376 @@
+package converter
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Dialect describes how fields are delimited, quoted and escaped in the input
+type Dialect struct {
+	Delimiter rune
+	Quote     rune   // 0 disables quoting
+	Escape    rune   // 0 means quotes are escaped by doubling them
+	Null      string // unquoted field value that represents NULL
+}
+
+// dialectPresets holds the named dialects accepted by ConversionOptions.Dialect
+var dialectPresets = map[string]Dialect{
+	"csv":           {Delimiter: ',', Quote: '"'},
+	"mysql":         {Delimiter: '\t', Quote: '"', Escape: '\\', Null: `\N`},
+	"postgres-text": {Delimiter: '\t', Escape: '\\', Null: `\N`},
+}
+
+// recordReader is implemented by csv.Reader and the custom dialect tokenizer
+type recordReader interface {
+	Read() ([]string, error)
+}
+
+// resolveDialect merges the named preset with the explicit delimiter, quote and escape options
+func resolveDialect(options ConversionOptions) (Dialect, error) {
+	dialect := dialectPresets["csv"]
+	if options.Dialect != "" {
+		preset, ok := dialectPresets[options.Dialect]
+		if !ok {
+			return Dialect{}, fmt.Errorf("unknown dialect %q", options.Dialect)
+		}
+		dialect = preset
+	}
+
+	if options.Delimiter != 0 {
+		dialect.Delimiter = options.Delimiter
+	}
+	if options.Quote != 0 {
+		dialect.Quote = options.Quote
+	}
+	if options.Escape != 0 {
+		dialect.Escape = options.Escape
+	}
+	// An escape equal to the quote, as in MySQL's ESCAPED BY '"', is quote doubling
+	if dialect.Escape == dialect.Quote {
+		dialect.Escape = 0
+	}
+	return dialect, nil
+}
+
+// newRecordReader returns the record reader for the input, wrapped to drop the
+// rows above the header row and the footer rows, and to report suspicious cells
+// when options.DetectFormulas is set
+func newRecordReader(reader io.Reader, options ConversionOptions) (recordReader, error) {
+	if options.SkipRows < 0 || options.HeaderRow < 0 || options.SkipFooter < 0 {
+		return nil, fmt.Errorf("skip rows, header row and skip footer must not be negative")
+	}
+
+	records, err := newInputReader(reader, options)
+	if err != nil {
+		return nil, err
+	}
+	if options.HeaderRow > 1 || options.SkipFooter > 0 {
+		records = &rowTrimmer{reader: records, skip: max(options.HeaderRow-1, 0), footer: options.SkipFooter}
+	}
+	if options.DetectFormulas && options.OnWarning != nil {
+		records = &formulaDetector{reader: records, warn: options.OnWarning}
+	}
+	return records, nil
+}
+
+// newInputReader returns the reader for the input format: encoding/csv for plain RFC 4180
+// input, the dialect tokenizer for other delimited text, or the fixed-width and xlsx readers
+func newInputReader(reader io.Reader, options ConversionOptions) (recordReader, error) {
+	switch options.InputFormat {
+	case "", "csv":
+	case "fixed":
+		if len(options.FixedWidths) == 0 {
+			return nil, fmt.Errorf("fixed-width input requires column widths")
+		}
+		fixedReader := newFixedWidthReader(skipLines(reader, options.SkipRows), options.FixedWidths)
+		fixedReader.comment = options.Comment
+		return fixedReader, nil
+	case "xlsx":
+		records, err := readXLSX(reader, options.Sheet)
+		if err != nil {
+			return nil, err
+		}
+		records = records[min(options.SkipRows, len(records)):]
+		return &sliceReader{records: records}, nil
+	default:
+		return nil, fmt.Errorf("unknown input format %q", options.InputFormat)
+	}
+
+	// An Excel "sep=" first line names the delimiter
+	reader, sep := readSepDirective(reader)
+	if sep != "" {
+		if err := options.SetDelimiter(sep); err != nil {
+			return nil, err
+		}
+	}
+	reader = skipLines(reader, options.SkipRows)
+
+	dialect, err := resolveDialect(options)
+	if err != nil {
+		return nil, err
+	}
+
+	separator := options.Separator
+	if len([]rune(separator)) == 1 {
+		dialect.Delimiter = []rune(separator)[0]
+		separator = ""
+	}
+	multiChar := separator != "" || options.SeparatorPattern != "" || options.SplitWhitespace
+
+	if !multiChar && dialect.Quote == '"' && dialect.Escape == 0 && dialect.Null == "" && len([]rune(options.Comment)) <= 1 {
+		// Excel's ="00123" text cells are quoted first, so quotes stay strict
+		csvReader := csv.NewReader(newExcelTextReader(reader, dialect.Delimiter, options.Comment))
+		csvReader.Comma = dialect.Delimiter
+		if options.Comment != "" {
+			csvReader.Comment = []rune(options.Comment)[0]
+		}
+		// Title rows above the header, footer rows and the fields of a vertical
+		// file rarely match the table's width
+		if options.HeaderRow > 1 || options.SkipFooter > 0 || options.Layout == "transpose" {
+			csvReader.FieldsPerRecord = -1
+		}
+		return csvReader, nil
+	}
+
+	dialectReader := newDialectReader(reader, dialect)
+	dialectReader.comment = options.Comment
+	switch {
+	case options.SplitWhitespace:
+		dialectReader.pattern = whitespaceRun
+		dialectReader.trimSpace = true
+	case options.SeparatorPattern != "":
+		pattern, err := regexp.Compile("^(?:" + options.SeparatorPattern + ")")
+		if err != nil {
+			return nil, fmt.Errorf("invalid delimiter pattern: %w", err)
+		}
+		if pattern.MatchString("") {
+			return nil, fmt.Errorf("delimiter pattern %q matches the empty string", options.SeparatorPattern)
+		}
+		dialectReader.pattern = pattern
+	case separator != "":
+		dialectReader.delim = separator
+	}
+	return dialectReader, nil
+}
+
+// whitespaceRun splits fields on runs of spaces and tabs, like awk
+var whitespaceRun = regexp.MustCompile(`^[ \t]+`)
+
+// readAllRecords drains a recordReader into memory
+func readAllRecords(reader recordReader) ([][]string, error) {
+	var records [][]string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+}
+
+// nullField is the text returned for a field holding the dialect's NULL marker.
+// parseValueUltra turns it into null whether or not types are inferred.
+const nullField = "\x00NULL\x00"
+
+// cellText returns the text of a field, reading NULL as empty, for uses such as
+// header names where a cell is not parsed into a value
+func cellText(field string) string {
+	if field == nullField {
+		return ""
+	}
+	return field
+}
+
+// dialectReader tokenizes delimited text with configurable quote and backslash-style escapes
+// and multi-character or pattern delimiters. NULL markers are returned as nullField.
+type dialectReader struct {
+	reader    *bufio.Reader
+	dialect   Dialect
+	delim     string
+	pattern   *regexp.Regexp // overrides delim when set
+	trimSpace bool
+	comment   string // lines starting with this prefix are skipped
+	line      int
+}
+
+func newDialectReader(reader io.Reader, dialect Dialect) *dialectReader {
+	return &dialectReader{
+		reader:  bufio.NewReader(reader),
+		dialect: dialect,
+		delim:   string(dialect.Delimiter),
+	}
+}
+
+// readLine returns the next physical line without its terminator
+func (d *dialectReader) readLine() (string, error) {
+	line, err := d.reader.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	if err != nil {
+		return "", err
+	}
+	d.line++
+	line = strings.TrimSuffix(line, "\n")
+	return strings.TrimSuffix(line, "\r"), nil
+}
+
+// Read returns the next record, joining physical lines for quoted or escaped newlines
+func (d *dialectReader) Read() ([]string, error) {
+	var line string
+	for line == "" {
+		var err error
+		if line, err = d.readLine(); err != nil {
+			return nil, err
+		}
+		if d.comment != "" && strings.HasPrefix(line, d.comment) {
+			line = ""
+			continue
+		}
+		if d.trimSpace {
+			line = strings.Trim(line, " \t")
+		}
+	}
+	startLine := d.line
+
+	var fields []string
+	var field strings.Builder
+	quoted, fieldStart := false, true
+	i := 0
+
+	for {
+		if fieldStart {
+			fieldStart = false
+			if d.dialect.Null != "" && d.isNullAt(line, i) {
+				fields = append(fields, nullField)
+				i += len(d.dialect.Null)
+				if i >= len(line) {
+					return fields, nil
+				}
+				i += d.delimiterAt(line, i)
+				fieldStart = true
+				continue
+			} else if d.dialect.Quote != 0 && strings.HasPrefix(line[i:], string(d.dialect.Quote)) {
+				quoted = true
+				i += len(string(d.dialect.Quote))
+			}
+		}
+
+		if i >= len(line) {
+			if !quoted {
+				fields = append(fields, field.String())
+				return fields, nil
+			}
+			next, err := d.readLine()
+			if err == io.EOF {
+				return nil, fmt.Errorf("line %d: unterminated quoted field", startLine)
+			}
+			if err != nil {
+				return nil, err
+			}
+			field.WriteByte('\n')
+			line, i = next, 0
+			continue
+		}
+
+		c, size := utf8.DecodeRuneInString(line[i:])
+		delimLen := 0
+		if !quoted {
+			delimLen = d.delimiterAt(line, i)
+		}
+
+		switch {
+		case d.dialect.Escape != 0 && c == d.dialect.Escape:
+			i += size
+			if i >= len(line) {
+				// An escape at the end of a line escapes the newline itself
+				next, err := d.readLine()
+				if err == io.EOF {
+					return nil, fmt.Errorf("line %d: escape character at end of input", d.line)
+				}
+				if err != nil {
+					return nil, err
+				}
+				field.WriteByte('\n')
+				line, i = next, 0
+				continue
+			}
+			escaped, escSize := utf8.DecodeRuneInString(line[i:])
+			field.WriteString(unescapeRune(escaped))
+			i += escSize
+
+		case quoted && c == d.dialect.Quote:
+			i += size
+			if d.dialect.Escape == 0 && strings.HasPrefix(line[i:], string(d.dialect.Quote)) {
+				field.WriteRune(c)
+				i += size
+			} else {
+				quoted = false
+			}
+
+		case delimLen > 0:
+			fields = append(fields, field.String())
+			field.Reset()
+			i += delimLen
+			fieldStart = true
+
+		default:
+			field.WriteString(line[i : i+size])
+			i += size
+		}
+	}
+}
+
+// isNullAt reports whether the field starting at i is exactly the NULL marker
+func (d *dialectReader) isNullAt(line string, i int) bool {
+	if !strings.HasPrefix(line[i:], d.dialect.Null) {
+		return false
+	}
+	end := i + len(d.dialect.Null)
+	return end == len(line) || d.delimiterAt(line, end) > 0
+}
+
+// delimiterAt returns the length of the delimiter starting at i, or 0 if there is none
+func (d *dialectReader) delimiterAt(line string, i int) int {
+	if d.pattern != nil {
+		if loc := d.pattern.FindStringIndex(line[i:]); loc != nil {
+			return loc[1]
+		}
+		return 0
+	}
+	if strings.HasPrefix(line[i:], d.delim) {
+		return len(d.delim)
+	}
+	return 0
+}
+
+// unescapeRune decodes the character following an escape character
+func unescapeRune(c rune) string {
+	switch c {
+	case 'n':
+		return "\n"
+	case 't':
+		return "\t"
+	case 'r':
+		return "\r"
+	case 'b':
+		return "\b"
+	case 'f':
+		return "\f"
+	case 'v':
+		return "\v"
+	case '0':
+		return "\x00"
+	case 'Z':
+		return "\x1a"
+	}
+	return string(c)
+}
@@ -0,0 +1,165 @@
+package converter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDialectReader(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		options  ConversionOptions
+		expected [][]string
+	}{
+		{
+			name:    "MySQL backslash escapes and NULL",
+			input:   "id\tname\tnote\n1\tO\\'Brien\tline\\nbreak\n2\t\\N\ttab\\there\n",
+			options: ConversionOptions{Dialect: "mysql"},
+			expected: [][]string{
+				{"id", "name", "note"},
+				{"1", "O'Brien", "line\nbreak"},
+				{"2", nullField, "tab\there"},
+			},
+		},
+		{
+			name:    "MySQL comma export enclosed by quotes",
+			input:   "1,\"say \\\"hi\\\"\",\\N\n2,\"a,b\",x\n",
+			options: ConversionOptions{Dialect: "mysql", Delimiter: ','},
+			expected: [][]string{
+				{"1", `say "hi"`, nullField},
+				{"2", "a,b", "x"},
+			},
+		},
+		{
+			name:    "Postgres text keeps quotes literal",
+			input:   "1\t\"quoted\"\t\\N\n2\tescaped\\\nnewline\tx\n",
+			options: ConversionOptions{Dialect: "postgres-text"},
+			expected: [][]string{
+				{"1", `"quoted"`, nullField},
+				{"2", "escaped\nnewline", "x"},
+			},
+		},
+		{
+			name:    "MySQL escaped by the quote character",
+			input:   "\"x\"\"y\",2\n",
+			options: ConversionOptions{Dialect: "mysql", Delimiter: ',', Escape: '"'},
+			expected: [][]string{
+				{`x"y`, "2"},
+			},
+		},
+		{
+			name:    "Custom quote character",
+			input:   "a,'b,c','it''s'\n",
+			options: ConversionOptions{Delimiter: ',', Quote: '\''},
+			expected: [][]string{
+				{"a", "b,c", "it's"},
+			},
+		},
+		{
+			name:    "Multi-character separator with quoted field",
+			input:   "a||b||c\n1||\"x||y\"||3\n",
+			options: ConversionOptions{Delimiter: ',', Separator: "||"},
+			expected: [][]string{
+				{"a", "b", "c"},
+				{"1", "x||y", "3"},
+			},
+		},
+		{
+			name:    "Regex separator",
+			input:   "a ~|~ b~|~c\n",
+			options: ConversionOptions{Delimiter: ',', SeparatorPattern: `\s*~\|~\s*`},
+			expected: [][]string{
+				{"a", "b", "c"},
+			},
+		},
+		{
+			name:    "Whitespace runs",
+			input:   "  name   age\t city\nJohn  30  \"New York\"  \n",
+			options: ConversionOptions{Delimiter: ',', SplitWhitespace: true},
+			expected: [][]string{
+				{"name", "age", "city"},
+				{"John", "30", "New York"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := newRecordReader(strings.NewReader(tt.input), tt.options)
+			if err != nil {
+				t.Fatalf("newRecordReader() error = %v", err)
+			}
+			records, err := readAllRecords(reader)
+			if err != nil {
+				t.Fatalf("readAllRecords() error = %v", err)
+			}
+			if !reflect.DeepEqual(records, tt.expected) {
+				t.Errorf("readAllRecords() = %q, want %q", records, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDialectNullBecomesJSONNull(t *testing.T) {
+	tests := []struct {
+		inferTypes bool
+		expected   string
+	}{
+		{true, `[{"id":1,"name":null,"note":null}]`},
+		{false, `[{"id":"1","name":null,"note":""}]`},
+	}
+
+	for _, tt := range tests {
+		options := DefaultOptions()
+		options.Dialect = "postgres-text"
+		options.Delimiter = 0
+		options.PrettyPrint = false
+		options.InferTypes = tt.inferTypes
+
+		result, err := ConvertCSVToJSON(strings.NewReader("id\tname\tnote\n1\t\\N\t\n"), options)
+		if err != nil {
+			t.Fatalf("ConvertCSVToJSON() error = %v", err)
+		}
+		if string(result) != tt.expected {
+			t.Errorf("ConvertCSVToJSON(infer types %v) = %s, want %s", tt.inferTypes, result, tt.expected)
+		}
+	}
+}
+
+func TestUnknownDialect(t *testing.T) {
+	options := DefaultOptions()
+	options.Dialect = "oracle"
+	if _, err := ConvertCSVToJSON(strings.NewReader("a\n1\n"), options); err == nil {
+		t.Error("ConvertCSVToJSON() expected error for unknown dialect")
+	}
+}
+
+func TestSetDelimiter(t *testing.T) {
+	tests := []struct {
+		value     string
+		delimiter rune
+		separator string
+		pattern   string
+		spaces    bool
+	}{
+		{value: ";", delimiter: ';'},
+		{value: "tab", delimiter: '\t'},
+		{value: "\\t", delimiter: '\t'},
+		{value: "::", delimiter: ',', separator: "::"},
+		{value: "regex:\\s+", delimiter: ',', pattern: "\\s+"},
+		{value: "whitespace", delimiter: ',', spaces: true},
+	}
+
+	for _, tt := range tests {
+		options := DefaultOptions()
+		if err := options.SetDelimiter(tt.value); err != nil {
+			t.Fatalf("SetDelimiter(%q) error = %v", tt.value, err)
+		}
+		if options.Delimiter != tt.delimiter || options.Separator != tt.separator ||
+			options.SeparatorPattern != tt.pattern || options.SplitWhitespace != tt.spaces {
+			t.Errorf("SetDelimiter(%q) = %+v", tt.value, options)
+		}
+	}
+}
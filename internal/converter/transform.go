@@ -0,0 +1,243 @@
+package converter
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// recordWriter consumes converted records one at a time. recordEncoder writes
+// them out; the transform stages rework them before passing them on.
+type recordWriter interface {
+	write(record map[string]interface{}) error
+	close() error
+}
+
+// NestSpec names a child array and the columns collected into it
+type NestSpec struct {
+	Name   string
+	Fields []string
+}
+
+// ParseNestSpec parses a nest spec such as "items=item_sku,qty"
+func ParseNestSpec(spec string) (NestSpec, error) {
+	name, fields, ok := strings.Cut(spec, "=")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return NestSpec{}, fmt.Errorf("invalid nest spec %q: expected name=column,column", spec)
+	}
+
+	nest := NestSpec{Name: name}
+	for _, field := range strings.Split(fields, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			nest.Fields = append(nest.Fields, field)
+		}
+	}
+	if len(nest.Fields) == 0 {
+		return NestSpec{}, fmt.Errorf("invalid nest spec %q: no columns given", spec)
+	}
+	return nest, nil
+}
+
+// hasRecordStages reports whether options transform records between parsing and encoding
+func hasRecordStages(options ConversionOptions) bool {
+	return len(options.GroupBy) > 0 || len(options.Nest) > 0 || options.Reshape != "" || options.Aggregate != nil
+}
+
+// newRecordPipeline returns the writer for the output format, wrapped in the
+// transform stages selected by options. Records are reshaped (pivot or
+// unpivot) before they are grouped or summarized.
+func newRecordPipeline(w *bufio.Writer, headers []string, options ConversionOptions) (recordWriter, error) {
+	var out recordWriter = newRecordEncoder(w, options)
+	if isKeyedFormat(options.OutputFormat) {
+		keyed, err := newKeyedEncoder(w, options)
+		if err != nil {
+			return nil, err
+		}
+		out = keyed
+	}
+
+	if !hasRecordStages(options) {
+		return out, nil
+	}
+	if options.OutputFormat == "object" {
+		return nil, fmt.Errorf("grouped or reshaped output does not support the %q format", options.OutputFormat)
+	}
+
+	if len(options.Nest) > 0 && len(options.GroupBy) == 0 {
+		return nil, fmt.Errorf("nesting columns requires group-by columns")
+	}
+	if options.Aggregate != nil && len(options.GroupBy) > 0 {
+		return nil, fmt.Errorf("aggregate cannot be combined with group-by; list the group columns in the aggregate spec")
+	}
+	if len(options.GroupBy) > 0 {
+		grouped := reshapedHeaders(headers, options)
+		if err := checkColumns(grouped, "group-by", options.GroupBy...); err != nil {
+			return nil, err
+		}
+		for _, nest := range options.Nest {
+			if err := checkColumns(grouped, "nest", nest.Fields...); err != nil {
+				return nil, err
+			}
+		}
+		out = newGrouper(out, options.GroupBy, options.Nest, options.GroupSorted)
+	}
+	if options.Aggregate != nil {
+		summarize, err := newSummarizer(out, reshapedHeaders(headers, options), *options.Aggregate)
+		if err != nil {
+			return nil, err
+		}
+		out = summarize
+	}
+
+	switch options.Reshape {
+	case "":
+	case "unpivot":
+		unpivot, err := newUnpivoter(out, headers, options)
+		if err != nil {
+			return nil, err
+		}
+		out = unpivot
+	case "pivot":
+		pivot, err := newPivoter(out, headers, options)
+		if err != nil {
+			return nil, err
+		}
+		out = pivot
+	default:
+		return nil, fmt.Errorf("unknown reshape %q: use pivot or unpivot", options.Reshape)
+	}
+	return out, nil
+}
+
+// reshapedHeaders returns the columns of the records leaving the reshape stage:
+// the input headers, the identifiers with the variable and value columns after
+// an unpivot, or nil after a pivot, whose columns come from the data
+func reshapedHeaders(headers []string, options ConversionOptions) []string {
+	switch {
+	case headers == nil || options.Reshape == "":
+		return headers
+	case options.Reshape == "unpivot":
+		columns := append([]string{}, options.IDColumns...)
+		return append(columns, reshapeName(options.VarName, DefaultVarName), reshapeName(options.ValueName, DefaultValueName))
+	}
+	return nil
+}
+
+// checkColumns returns an error for the first column that is not one of the
+// headers. Nil headers are not known up front, as with empty input, and pass.
+func checkColumns(headers []string, role string, columns ...string) error {
+	if headers == nil {
+		return nil
+	}
+	known := make(map[string]bool, len(headers))
+	for _, header := range headers {
+		known[header] = true
+	}
+	for _, column := range columns {
+		if !known[column] {
+			return fmt.Errorf("unknown %s column %q", role, column)
+		}
+	}
+	return nil
+}
+
+// grouper merges the records that share the group-by columns into one
+// document. The other columns are taken from the group's first record, and
+// each nest spec collects its columns from every record into a child array.
+// Sorted input is streamed group by group; otherwise groups are held in a hash
+// table and written in first-seen order when the input ends.
+type grouper struct {
+	next   recordWriter
+	keys   []string
+	nests  []NestSpec
+	nested map[string]bool
+	sorted bool
+
+	order  []string
+	groups map[string]map[string]interface{}
+}
+
+func newGrouper(next recordWriter, keys []string, nests []NestSpec, sorted bool) *grouper {
+	nested := make(map[string]bool)
+	for _, nest := range nests {
+		for _, field := range nest.Fields {
+			nested[field] = true
+		}
+	}
+	return &grouper{
+		next:   next,
+		keys:   keys,
+		nests:  nests,
+		nested: nested,
+		sorted: sorted,
+		groups: make(map[string]map[string]interface{}),
+	}
+}
+
+func (g *grouper) write(record map[string]interface{}) error {
+	keyValues := make([]interface{}, len(g.keys))
+	for i, key := range g.keys {
+		keyValues[i] = record[key]
+	}
+	keyJSON, err := json.Marshal(keyValues)
+	if err != nil {
+		return err
+	}
+	key := string(keyJSON)
+
+	doc, ok := g.groups[key]
+	if !ok {
+		// Sorted input starts a new group only after the previous one ends
+		if g.sorted {
+			if err := g.flush(); err != nil {
+				return err
+			}
+		}
+		doc = make(map[string]interface{}, len(record))
+		for field, value := range record {
+			if !g.nested[field] {
+				doc[field] = value
+			}
+		}
+		for _, nest := range g.nests {
+			doc[nest.Name] = []interface{}{}
+		}
+		g.groups[key] = doc
+		g.order = append(g.order, key)
+	}
+
+	for _, nest := range g.nests {
+		child := make(map[string]interface{}, len(nest.Fields))
+		empty := true
+		for _, field := range nest.Fields {
+			child[field] = record[field]
+			empty = empty && record[field] == nil
+		}
+		// A parent without children appears as a row of empty child columns
+		if !empty {
+			doc[nest.Name] = append(doc[nest.Name].([]interface{}), child)
+		}
+	}
+	return nil
+}
+
+// flush writes out the groups collected so far
+func (g *grouper) flush() error {
+	for _, key := range g.order {
+		if err := g.next.write(g.groups[key]); err != nil {
+			return err
+		}
+		delete(g.groups, key)
+	}
+	g.order = g.order[:0]
+	return nil
+}
+
+func (g *grouper) close() error {
+	if err := g.flush(); err != nil {
+		return err
+	}
+	return g.next.close()
+}
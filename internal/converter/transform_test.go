@@ -0,0 +1,124 @@
+package converter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestGroupBy(t *testing.T) {
+	input := "order_id,customer,item_sku,qty\n" +
+		"1,Ann,A-1,2\n" +
+		"1,Ann,B-2,1\n" +
+		"2,Bob,,\n" +
+		"1,Ann,C-3,5\n"
+
+	tests := []struct {
+		name     string
+		sorted   bool
+		expected string
+	}{
+		{
+			name: "Hash grouping merges non-adjacent rows",
+			expected: `[{"customer":"Ann","items":[{"item_sku":"A-1","qty":2},{"item_sku":"B-2","qty":1},{"item_sku":"C-3","qty":5}],"order_id":1},` +
+				`{"customer":"Bob","items":[],"order_id":2}]`,
+		},
+		{
+			name:   "Sorted grouping streams adjacent rows",
+			sorted: true,
+			expected: `[{"customer":"Ann","items":[{"item_sku":"A-1","qty":2},{"item_sku":"B-2","qty":1}],"order_id":1},` +
+				`{"customer":"Bob","items":[],"order_id":2},` +
+				`{"customer":"Ann","items":[{"item_sku":"C-3","qty":5}],"order_id":1}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nest, err := ParseNestSpec("items=item_sku, qty")
+			if err != nil {
+				t.Fatalf("ParseNestSpec failed: %v", err)
+			}
+			options := DefaultOptions()
+			options.PrettyPrint = false
+			options.GroupBy = []string{"order_id"}
+			options.Nest = []NestSpec{nest}
+			options.GroupSorted = tt.sorted
+
+			result, err := ConvertCSVToJSON(strings.NewReader(input), options)
+			if err != nil {
+				t.Fatalf("ConvertCSVToJSON failed: %v", err)
+			}
+			if string(result) != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, result)
+			}
+
+			var streamed bytes.Buffer
+			if err := ConvertCSVToJSONStream(strings.NewReader(input), &streamed, options); err != nil {
+				t.Fatalf("ConvertCSVToJSONStream failed: %v", err)
+			}
+			if streamed.String() != tt.expected {
+				t.Errorf("stream: expected %s, got %s", tt.expected, streamed.String())
+			}
+		})
+	}
+}
+
+func TestGroupByErrors(t *testing.T) {
+	if _, err := ParseNestSpec("items"); err == nil {
+		t.Error("expected an error for a nest spec without columns")
+	}
+
+	options := DefaultOptions()
+	options.Nest = []NestSpec{{Name: "items", Fields: []string{"sku"}}}
+	if _, err := ConvertCSVToJSON(strings.NewReader("id,sku\n1,a\n"), options); err == nil {
+		t.Error("expected an error for nesting without group-by")
+	}
+
+	options.GroupBy = []string{"id"}
+	options.OutputFormat = "object"
+	if _, err := ConvertCSVToJSON(strings.NewReader("id,sku\n1,a\n"), options); err == nil {
+		t.Error("expected an error for grouped object output")
+	}
+}
+
+func TestGroupByUnknownColumns(t *testing.T) {
+	input := "order_id,item_sku,qty\n1,A-1,2\n1,B-2,1\n2,C-3,5\n"
+
+	tests := []struct {
+		name    string
+		groupBy []string
+		nest    []NestSpec
+		err     string
+	}{
+		{"Misspelled group-by column", []string{"nope"}, nil, `unknown group-by column "nope"`},
+		{"Misspelled nest column", []string{"order_id"}, []NestSpec{{Name: "items", Fields: []string{"item_sku", "qtty"}}}, `unknown nest column "qtty"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := DefaultOptions()
+			options.GroupBy = tt.groupBy
+			options.Nest = tt.nest
+
+			_, err := ConvertCSVToJSON(strings.NewReader(input), options)
+			var streamed bytes.Buffer
+			streamErr := ConvertCSVToJSONStream(strings.NewReader(input), &streamed, options)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected error containing %q, got %v", tt.err, err)
+			}
+			if streamErr == nil || !strings.Contains(streamErr.Error(), tt.err) {
+				t.Errorf("stream: expected error containing %q, got %v", tt.err, streamErr)
+			}
+		})
+	}
+
+	// Columns made by an unpivot can be grouped on
+	options := DefaultOptions()
+	options.PrettyPrint = false
+	options.Reshape = "unpivot"
+	options.IDColumns = []string{"order_id"}
+	options.GroupBy = []string{"variable"}
+	if _, err := ConvertCSVToJSON(strings.NewReader(input), options); err != nil {
+		t.Errorf("grouping on an unpivoted column failed: %v", err)
+	}
+}
@@ -1,12 +1,14 @@
 package converter
 
 import (
-	"encoding/csv"
+	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
 	"runtime"
 	"strconv"
+	"strings"
 	"sync"
 )
 
@@ -63,37 +65,188 @@ func DefaultUltraOptimizedOptions() UltraOptimizedOptions {
 
 // ConvertCSVToJSONUltra converts CSV data using ultra-optimized implementation
 func ConvertCSVToJSONUltra(reader io.Reader, options UltraOptimizedOptions) ([]byte, error) {
-	csvReader := csv.NewReader(reader)
-	csvReader.Comma = options.ConversionOptions.Delimiter
+	if options.MultiTable {
+		return convertMultiTable(reader, options)
+	}
+
+	csvReader, err := newRecordReader(reader, options.ConversionOptions)
+	if err != nil {
+		return nil, err
+	}
 
-	records, err := csvReader.ReadAll()
+	records, err := readAllRecords(csvReader)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read CSV: %w", err)
 	}
 
 	if len(records) == 0 {
+		// Transform stages still run, so that an aggregate reports a count of 0
+		// just as the streaming converter does
+		if options.Layout != "keyvalue" && (hasRecordStages(options.ConversionOptions) || isKeyedFormat(options.ConversionOptions.OutputFormat)) {
+			return convertToNDJSONUltra(nil, nil, options)
+		}
+		if options.Layout == "keyvalue" || isKeyedFormat(options.ConversionOptions.OutputFormat) {
+			return []byte("{}"), nil
+		}
+		if options.ConversionOptions.OutputFormat == "ndjson" {
+			return []byte{}, nil
+		}
 		return []byte("[]"), nil
 	}
 
+	switch options.Layout {
+	case "", "table":
+	case "transpose":
+		records = transposeRecords(records)
+	case "keyvalue":
+		_, dataRows, err := splitHeader(records, options.ConversionOptions)
+		if err != nil {
+			return nil, err
+		}
+		return convertToKeyValueUltra(dataRows, options)
+	default:
+		return nil, fmt.Errorf("unknown layout %q", options.Layout)
+	}
+
+	headers, dataRows, err := splitHeader(records, options.ConversionOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	if hasRecordStages(options.ConversionOptions) || isKeyedFormat(options.ConversionOptions.OutputFormat) {
+		return convertToNDJSONUltra(dataRows, headers, options)
+	}
+
+	switch options.ConversionOptions.OutputFormat {
+	case "object":
+		return convertToObjectUltra(dataRows, headers, options)
+	case "ndjson":
+		return convertToNDJSONUltra(dataRows, headers, options)
+	}
+	
+	return convertToArrayUltra(dataRows, headers, options)
+}
+
+// headerRowCount returns how many records splitHeader takes the header from
+func headerRowCount(options ConversionOptions) int {
+	if options.HasHeader && !(options.InputFormat == "fixed" && fixedWidthHeaders(options.FixedWidths) != nil) {
+		return max(options.HeaderRows, 1)
+	}
+	return 1
+}
+
+// readHeader reads enough records from reader to split off the header,
+// returning the data rows read along with it. It returns io.EOF for empty input.
+func readHeader(reader recordReader, options ConversionOptions) ([]string, [][]string, error) {
+	var records [][]string
+	for len(records) < headerRowCount(options) {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		records = append(records, record)
+	}
+	if len(records) == 0 {
+		return nil, nil, io.EOF
+	}
+
+	return splitHeader(records, options)
+}
+
+// splitHeader separates the header from the data rows. Headers supplied in
+// options.Headers replace the header row, and column names are generated from
+// options.ColumnNames when the input has no header row.
+func splitHeader(records [][]string, options ConversionOptions) ([]string, [][]string, error) {
 	var headers []string
 	var dataRows [][]string
 
-	if options.ConversionOptions.HasHeader {
-		headers = records[0]
-		dataRows = records[1:]
+	if options.InputFormat == "fixed" && fixedWidthHeaders(options.FixedWidths) != nil {
+		// Named fixed-width columns supply the header, so every line is data
+		headers = fixedWidthHeaders(options.FixedWidths)
+		dataRows = records
+	} else if options.HasHeader {
+		n := min(headerRowCount(options), len(records))
+		headers = combineHeaderRows(records[:n])
+		dataRows = records[n:]
 	} else {
 		// Generate generic headers
-		for i := 0; i < len(records[0]); i++ {
-			headers = append(headers, fmt.Sprintf("column_%d", i+1))
+		var err error
+		if headers, err = columnNames(options.ColumnNames, len(records[0])); err != nil {
+			return nil, nil, err
 		}
 		dataRows = records
 	}
 
-	if options.ConversionOptions.OutputFormat == "object" {
-		return convertToObjectUltra(dataRows, headers, options)
+	if len(options.Headers) > 0 {
+		if len(options.Headers) != len(headers) {
+			return nil, nil, fmt.Errorf("%d headers were supplied but the data has %d columns", len(options.Headers), len(headers))
+		}
+		headers = options.Headers
 	}
-	
-	return convertToArrayUltra(dataRows, headers, options)
+
+	return headers, dataRows, nil
+}
+
+// combineHeaderRows merges stacked header rows into compound keys such as
+// "Q1.Revenue". A blank cell in an upper row continues the label to its left
+// (a spanning cell), as long as the rows above it continue too; blank parts are
+// left out of the key.
+func combineHeaderRows(rows [][]string) []string {
+	if len(rows) == 1 {
+		headers := make([]string, len(rows[0]))
+		for i, cell := range rows[0] {
+			headers[i] = cellText(cell)
+		}
+		return headers
+	}
+
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row))
+	}
+
+	parts := make([][]string, width)
+	for level, row := range rows {
+		last := level == len(rows)-1
+		for col := 0; col < width; col++ {
+			cell := ""
+			if col < len(row) {
+				cell = strings.TrimSpace(cellText(row[col]))
+			}
+			if cell == "" && !last && col > 0 && sameLabels(parts[col], parts[col-1][:level]) {
+				cell = parts[col-1][level]
+			}
+			parts[col] = append(parts[col], cell)
+		}
+	}
+
+	headers := make([]string, width)
+	for col, labels := range parts {
+		var key []string
+		for _, label := range labels {
+			if label != "" {
+				key = append(key, label)
+			}
+		}
+		headers[col] = strings.Join(key, ".")
+	}
+	return headers
+}
+
+// sameLabels reports whether two header label paths are equal
+func sameLabels(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
 }
 
 // convertToArrayUltra uses ultra-optimizations for array format
@@ -105,7 +258,7 @@ func convertToArrayUltra(dataRows [][]string, headers []string, options UltraOpt
 	}
 
 	rowChan := make(chan int, len(dataRows))
-	resultChan := make(chan map[string]interface{}, len(dataRows))
+	jsonArray := make([]map[string]interface{}, len(dataRows))
 	
 	var wg sync.WaitGroup
 
@@ -115,8 +268,8 @@ func convertToArrayUltra(dataRows [][]string, headers []string, options UltraOpt
 		go func() {
 			defer wg.Done()
 			for rowIdx := range rowChan {
-				obj := processRowUltra(dataRows[rowIdx], headers, options)
-				resultChan <- obj
+				// Each worker writes its own slot, preserving input order
+				jsonArray[rowIdx] = processRowUltra(dataRows[rowIdx], headers, options)
 			}
 		}()
 	}
@@ -129,16 +282,7 @@ func convertToArrayUltra(dataRows [][]string, headers []string, options UltraOpt
 		}
 	}()
 
-	// Collect results
-	go func() {
-		wg.Wait()
-		close(resultChan)
-	}()
-
-	jsonArray := make([]map[string]interface{}, 0, len(dataRows))
-	for obj := range resultChan {
-		jsonArray = append(jsonArray, obj)
-	}
+	wg.Wait()
 
 	// Use fast JSON marshaling
 	if options.ConversionOptions.PrettyPrint {
@@ -147,6 +291,30 @@ func convertToArrayUltra(dataRows [][]string, headers []string, options UltraOpt
 	return json.Marshal(jsonArray)
 }
 
+// convertToNDJSONUltra writes one compact JSON object per line. It also
+// serves the keyed format and array output that passes through transform
+// stages such as grouping.
+func convertToNDJSONUltra(dataRows [][]string, headers []string, options UltraOptimizedOptions) ([]byte, error) {
+	var buf bytes.Buffer
+	out := bufio.NewWriter(&buf)
+	pipeline, err := newRecordPipeline(out, headers, options.ConversionOptions)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range dataRows {
+		if err := pipeline.write(processRowUltra(row, headers, options)); err != nil {
+			return nil, err
+		}
+	}
+	if err := pipeline.close(); err != nil {
+		return nil, err
+	}
+	if err := out.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
 // convertToObjectUltra uses ultra-optimizations for object format
 func convertToObjectUltra(dataRows [][]string, headers []string, options UltraOptimizedOptions) ([]byte, error) {
 	jsonObj := make(map[string]interface{})
@@ -160,27 +328,7 @@ func convertToObjectUltra(dataRows [][]string, headers []string, options UltraOp
 		go func(colIdx int, colName string) {
 			defer wg.Done()
 			
-			var column []interface{}
-			if options.UseMemoryPools {
-				slice := slicePool.Get().([]interface{})
-				column = slice[:0] // Reset length but keep capacity
-				defer slicePool.Put(column)
-			} else {
-				column = make([]interface{}, 0, len(dataRows))
-			}
-			
-			for _, row := range dataRows {
-				if colIdx < len(row) {
-					value := parseValueUltra(row[colIdx], options.ConversionOptions.InferTypes, options.SIMDEnabled)
-					column = append(column, value)
-				} else {
-					column = append(column, nil)
-				}
-			}
-			
-			// Copy column data before returning to pool
-			finalColumn := make([]interface{}, len(column))
-			copy(finalColumn, column)
+			finalColumn := parseColumnUltra(dataRows, colIdx, options)
 			
 			mu.Lock()
 			jsonObj[colName] = finalColumn
@@ -196,6 +344,32 @@ func convertToObjectUltra(dataRows [][]string, headers []string, options UltraOp
 	return json.Marshal(jsonObj)
 }
 
+// parseColumnUltra parses one column of the data rows, using null for short rows
+func parseColumnUltra(dataRows [][]string, colIdx int, options UltraOptimizedOptions) []interface{} {
+	var column []interface{}
+	if options.UseMemoryPools {
+		slice := slicePool.Get().([]interface{})
+		column = slice[:0] // Reset length but keep capacity
+		defer slicePool.Put(column)
+	} else {
+		column = make([]interface{}, 0, len(dataRows))
+	}
+
+	for _, row := range dataRows {
+		if colIdx < len(row) {
+			value := parseValueUltra(row[colIdx], options.ConversionOptions.InferTypes, options.SIMDEnabled)
+			column = append(column, value)
+		} else {
+			column = append(column, nil)
+		}
+	}
+
+	// Copy column data before returning to pool
+	finalColumn := make([]interface{}, len(column))
+	copy(finalColumn, column)
+	return finalColumn
+}
+
 // processRowUltra processes a single row with ultra-optimizations
 func processRowUltra(row []string, headers []string, options UltraOptimizedOptions) map[string]interface{} {
 	var obj map[string]interface{}
@@ -214,9 +388,15 @@ func processRowUltra(row []string, headers []string, options UltraOptimizedOptio
 	// Create result map (copy from pooled object)
 	result := make(map[string]interface{}, len(headers))
 	
+	// Keyed output is indexed by the key column's text rather than its typed value
+	keyed := isKeyedFormat(options.ConversionOptions.OutputFormat) && !hasRecordStages(options.ConversionOptions)
+
 	for j, header := range headers {
 		if j < len(row) {
 			value := parseValueUltra(row[j], options.ConversionOptions.InferTypes, options.SIMDEnabled)
+			if keyed && header == options.ConversionOptions.Key {
+				value = keyCell{text: cellText(row[j]), value: value}
+			}
 			result[header] = value
 		} else {
 			result[header] = nil
@@ -228,6 +408,15 @@ func processRowUltra(row []string, headers []string, options UltraOptimizedOptio
 
 // parseValueUltra provides ultra-fast type inference with SIMD-style optimizations
 func parseValueUltra(s string, inferTypes bool, simdEnabled bool) interface{} {
+	if s == nullField {
+		return nil
+	}
+
+	// Excel's ="00123" marks text that must not be read as a number
+	if text, ok := excelTextValue(s); ok {
+		return text
+	}
+
 	if !inferTypes {
 		return s
 	}
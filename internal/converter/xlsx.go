@@ -0,0 +1,378 @@
+package converter
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SpreadsheetML parts of an .xlsx workbook, decoded with encoding/xml
+type xlsxWorkbook struct {
+	WorkbookPr struct {
+		Date1904 bool `xml:"date1904,attr"`
+	} `xml:"workbookPr"`
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxRichText struct {
+	T string `xml:"t"`
+	R []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (r xlsxRichText) String() string {
+	if len(r.R) == 0 {
+		return r.T
+	}
+	var sb strings.Builder
+	for _, run := range r.R {
+		sb.WriteString(run.T)
+	}
+	return sb.String()
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxRichText `xml:"si"`
+}
+
+type xlsxStyles struct {
+	NumFmts []struct {
+		ID   int    `xml:"numFmtId,attr"`
+		Code string `xml:"formatCode,attr"`
+	} `xml:"numFmts>numFmt"`
+	CellXfs []struct {
+		NumFmtID int `xml:"numFmtId,attr"`
+	} `xml:"cellXfs>xf"`
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		Ref   int `xml:"r,attr"`
+		Cells []struct {
+			Ref    string       `xml:"r,attr"`
+			Type   string       `xml:"t,attr"`
+			Style  int          `xml:"s,attr"`
+			Value  string       `xml:"v"`
+			Inline xlsxRichText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+	MergeCells []struct {
+		Ref string `xml:"ref,attr"`
+	} `xml:"mergeCells>mergeCell"`
+}
+
+// readXLSX loads the selected sheet of an .xlsx workbook as text records. The
+// sheet may be given by name or 1-based index and defaults to the first one.
+func readXLSX(reader io.Reader, sheet string) ([][]string, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("not an xlsx file: %w", err)
+	}
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+
+	var workbook xlsxWorkbook
+	if err := decodeXLSXPart(files, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	var rels xlsxRelationships
+	if err := decodeXLSXPart(files, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+
+	var sharedStrings xlsxSharedStrings
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decodeXLSXPart(files, "xl/sharedStrings.xml", &sharedStrings); err != nil {
+			return nil, err
+		}
+	}
+	var styles xlsxStyles
+	if _, ok := files["xl/styles.xml"]; ok {
+		if err := decodeXLSXPart(files, "xl/styles.xml", &styles); err != nil {
+			return nil, err
+		}
+	}
+
+	sheetPath, err := xlsxSheetPath(workbook, rels, sheet)
+	if err != nil {
+		return nil, err
+	}
+	var worksheet xlsxWorksheet
+	if err := decodeXLSXPart(files, sheetPath, &worksheet); err != nil {
+		return nil, err
+	}
+
+	dateStyles := xlsxDateStyles(styles)
+	records := make([][]string, 0, len(worksheet.Rows))
+	rowIndex := make(map[int]int, len(worksheet.Rows))
+
+	for i, row := range worksheet.Rows {
+		var record []string
+		for j, cell := range row.Cells {
+			col := j
+			if cell.Ref != "" {
+				var err error
+				if col, _, err = parseCellRef(cell.Ref); err != nil {
+					return nil, err
+				}
+			}
+			for len(record) <= col {
+				record = append(record, "")
+			}
+
+			switch cell.Type {
+			case "s":
+				idx, err := strconv.Atoi(cell.Value)
+				if err != nil || idx < 0 || idx >= len(sharedStrings.Items) {
+					return nil, fmt.Errorf("cell %s: invalid shared string index %q", cell.Ref, cell.Value)
+				}
+				record[col] = sharedStrings.Items[idx].String()
+			case "inlineStr":
+				record[col] = cell.Inline.String()
+			case "b":
+				record[col] = strconv.FormatBool(cell.Value == "1")
+			case "n", "":
+				record[col] = cell.Value
+				if cell.Value != "" && cell.Style < len(dateStyles) && dateStyles[cell.Style] {
+					if serial, err := strconv.ParseFloat(cell.Value, 64); err == nil {
+						record[col] = formatExcelDate(serial, workbook.WorkbookPr.Date1904)
+					}
+				}
+			default:
+				// "str" formula results, "d" ISO dates and "e" errors are kept as text
+				record[col] = cell.Value
+			}
+		}
+
+		if len(record) == 0 {
+			continue
+		}
+		rowNumber := row.Ref
+		if rowNumber == 0 {
+			rowNumber = i + 1
+		}
+		rowIndex[rowNumber] = len(records)
+		records = append(records, record)
+	}
+
+	// Copy the top-left value of merged ranges into every covered cell
+	for _, merge := range worksheet.MergeCells {
+		from, to, ok := strings.Cut(merge.Ref, ":")
+		if !ok {
+			continue
+		}
+		col1, row1, err1 := parseCellRef(from)
+		col2, row2, err2 := parseCellRef(to)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		origin, ok := rowIndex[row1]
+		if !ok || col1 >= len(records[origin]) {
+			continue
+		}
+		value := records[origin][col1]
+		for r := row1; r <= row2; r++ {
+			idx, ok := rowIndex[r]
+			if !ok {
+				continue
+			}
+			for len(records[idx]) <= col2 {
+				records[idx] = append(records[idx], "")
+			}
+			for c := col1; c <= col2; c++ {
+				records[idx][c] = value
+			}
+		}
+	}
+
+	return records, nil
+}
+
+// decodeXLSXPart unmarshals one XML part of the workbook archive
+func decodeXLSXPart(files map[string]*zip.File, name string, v interface{}) error {
+	file, ok := files[name]
+	if !ok {
+		return fmt.Errorf("xlsx file is missing %s", name)
+	}
+	rc, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	if err := xml.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	return nil
+}
+
+// xlsxSheetPath resolves a sheet name or 1-based index to its part name in the archive
+func xlsxSheetPath(workbook xlsxWorkbook, rels xlsxRelationships, sheet string) (string, error) {
+	if len(workbook.Sheets) == 0 {
+		return "", fmt.Errorf("workbook has no sheets")
+	}
+
+	selected := -1
+	if sheet == "" {
+		selected = 0
+	}
+	for i, s := range workbook.Sheets {
+		if selected < 0 && s.Name == sheet {
+			selected = i
+		}
+	}
+	if selected < 0 {
+		if n, err := strconv.Atoi(sheet); err == nil && n >= 1 && n <= len(workbook.Sheets) {
+			selected = n - 1
+		}
+	}
+	if selected < 0 {
+		names := make([]string, len(workbook.Sheets))
+		for i, s := range workbook.Sheets {
+			names[i] = s.Name
+		}
+		return "", fmt.Errorf("sheet %q not found (available: %s)", sheet, strings.Join(names, ", "))
+	}
+
+	for _, rel := range rels.Relationships {
+		if rel.ID == workbook.Sheets[selected].RID {
+			if strings.HasPrefix(rel.Target, "/") {
+				return strings.TrimPrefix(rel.Target, "/"), nil
+			}
+			return path.Join("xl", rel.Target), nil
+		}
+	}
+	return "", fmt.Errorf("sheet %q has no worksheet part", workbook.Sheets[selected].Name)
+}
+
+// xlsxDateStyles reports, for each cell style index, whether it formats numbers as dates
+func xlsxDateStyles(styles xlsxStyles) []bool {
+	customFormats := make(map[int]string, len(styles.NumFmts))
+	for _, numFmt := range styles.NumFmts {
+		customFormats[numFmt.ID] = numFmt.Code
+	}
+
+	dateStyles := make([]bool, len(styles.CellXfs))
+	for i, xf := range styles.CellXfs {
+		id := xf.NumFmtID
+		switch {
+		case id >= 14 && id <= 22, id >= 45 && id <= 47:
+			dateStyles[i] = true
+		case customFormats[id] != "":
+			dateStyles[i] = isDateFormatCode(customFormats[id])
+		}
+	}
+	return dateStyles
+}
+
+// isDateFormatCode reports whether a custom number format contains date or time tokens
+func isDateFormatCode(code string) bool {
+	inQuote, inBracket := false, false
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		switch {
+		case c == '"':
+			inQuote = !inQuote
+		case inQuote:
+		case c == '\\':
+			i++
+		case c == '[':
+			inBracket = true
+		case c == ']':
+			inBracket = false
+		case inBracket:
+		case strings.ContainsRune("dmyhsDMYHS", rune(c)):
+			return true
+		}
+	}
+	return false
+}
+
+// formatExcelDate converts an Excel serial date to ISO 8601 text
+func formatExcelDate(serial float64, date1904 bool) string {
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	if date1904 {
+		epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	days := math.Floor(serial)
+	seconds := math.Round((serial - days) * 86400)
+	t := epoch.AddDate(0, 0, int(days)).Add(time.Duration(seconds) * time.Second)
+
+	switch {
+	case days == 0 && seconds != 0 && !date1904:
+		return t.Format("15:04:05")
+	case seconds == 0:
+		return t.Format("2006-01-02")
+	}
+	return t.Format("2006-01-02T15:04:05")
+}
+
+// Excel's sheet limits: columns A to XFD and rows 1 to 1048576
+const (
+	xlsxMaxColumns = 16384
+	xlsxMaxRows    = 1048576
+)
+
+// parseCellRef converts an A1-style reference to zero-based column and 1-based
+// row numbers, rejecting references outside Excel's sheet limits
+func parseCellRef(ref string) (col int, row int, err error) {
+	i := 0
+	for i < len(ref) && ref[i] >= 'A' && ref[i] <= 'Z' {
+		col = col*26 + int(ref[i]-'A'+1)
+		if col > xlsxMaxColumns {
+			return 0, 0, fmt.Errorf("cell reference %q is beyond column XFD", ref)
+		}
+		i++
+	}
+	if i == 0 {
+		return 0, 0, fmt.Errorf("invalid cell reference %q", ref)
+	}
+	row, err = strconv.Atoi(ref[i:])
+	if err != nil || row < 1 {
+		return 0, 0, fmt.Errorf("invalid cell reference %q", ref)
+	}
+	if row > xlsxMaxRows {
+		return 0, 0, fmt.Errorf("cell reference %q is beyond row %d", ref, xlsxMaxRows)
+	}
+	return col - 1, row, nil
+}
+
+// sliceReader serves records that were loaded up front, such as a spreadsheet
+type sliceReader struct {
+	records [][]string
+}
+
+func (s *sliceReader) Read() ([]string, error) {
+	if len(s.records) == 0 {
+		return nil, io.EOF
+	}
+	record := s.records[0]
+	s.records = s.records[1:]
+	return record, nil
+}
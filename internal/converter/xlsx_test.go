@@ -0,0 +1,161 @@
+package converter
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// buildXLSX zips the given SpreadsheetML parts into an in-memory workbook
+func buildXLSX(t *testing.T, parts map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range parts {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+var testWorkbookParts = map[string]string{
+	"xl/workbook.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+  <sheets>
+    <sheet name="Notes" sheetId="1" r:id="rId1"/>
+    <sheet name="Staff" sheetId="2" r:id="rId2"/>
+  </sheets>
+</workbook>`,
+	"xl/_rels/workbook.xml.rels": `<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Target="worksheets/sheet1.xml"/>
+  <Relationship Id="rId2" Target="/xl/worksheets/sheet2.xml"/>
+</Relationships>`,
+	"xl/sharedStrings.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <si><t>name</t></si>
+  <si><t>joined</t></si>
+  <si><r><t>Ali</t></r><r><t>ce</t></r></si>
+  <si><t>active</t></si>
+  <si><t>Details</t></si>
+</sst>`,
+	"xl/styles.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <numFmts><numFmt numFmtId="164" formatCode="dd/mm/yyyy"/></numFmts>
+  <cellXfs><xf numFmtId="0"/><xf numFmtId="164"/><xf numFmtId="22"/></cellXfs>
+</styleSheet>`,
+	"xl/worksheets/sheet1.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <sheetData>
+    <row r="1"><c r="A1" t="inlineStr"><is><t>note</t></is></c><c r="B1" t="inlineStr"><is><t>count</t></is></c></row>
+    <row r="2"><c r="A2" t="inlineStr"><is><t>hello</t></is></c><c r="B2"><v>3</v></c></row>
+  </sheetData>
+</worksheet>`,
+	"xl/worksheets/sheet2.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <sheetData>
+    <row r="1"><c r="B1" t="s"><v>4</v></c></row>
+    <row r="2"><c r="A2" t="s"><v>0</v></c><c r="B2" t="s"><v>1</v></c><c r="C2" t="s"><v>3</v></c><c r="D2" t="inlineStr"><is><t>score</t></is></c></row>
+    <row r="3"><c r="A3" t="s"><v>2</v></c><c r="B3" s="1"><v>44197</v></c><c r="C3" t="b"><v>1</v></c><c r="D3"><v>9.5</v></c></row>
+    <row r="5"><c r="A5" t="str"><v>Bob</v></c><c r="B5" s="2"><v>44197.5</v></c><c r="C5" t="b"><v>0</v></c></row>
+  </sheetData>
+  <mergeCells><mergeCell ref="B1:D1"/></mergeCells>
+</worksheet>`,
+}
+
+func TestReadXLSX(t *testing.T) {
+	data := buildXLSX(t, testWorkbookParts)
+
+	records, err := readXLSX(bytes.NewReader(data), "Staff")
+	if err != nil {
+		t.Fatalf("readXLSX() error = %v", err)
+	}
+
+	expected := [][]string{
+		{"", "Details", "Details", "Details"},
+		{"name", "joined", "active", "score"},
+		{"Alice", "2021-01-01", "true", "9.5"},
+		{"Bob", "2021-01-01T12:00:00", "false"},
+	}
+	if !reflect.DeepEqual(records, expected) {
+		t.Errorf("readXLSX() = %q, want %q", records, expected)
+	}
+
+	if records, err := readXLSX(bytes.NewReader(data), "1"); err != nil || records[0][0] != "note" {
+		t.Errorf("readXLSX() by index = %q, %v", records, err)
+	}
+	if _, err := readXLSX(bytes.NewReader(data), "Missing"); err == nil {
+		t.Error("readXLSX() expected error for missing sheet")
+	}
+}
+
+func TestConvertXLSX(t *testing.T) {
+	options := DefaultOptions()
+	options.InputFormat = "xlsx"
+
+	result, err := ConvertCSVToJSON(bytes.NewReader(buildXLSX(t, testWorkbookParts)), options)
+	if err != nil {
+		t.Fatalf("ConvertCSVToJSON() error = %v", err)
+	}
+
+	var records []map[string]interface{}
+	if err := json.Unmarshal(result, &records); err != nil {
+		t.Fatalf("Failed to parse result JSON: %v", err)
+	}
+	expected := []map[string]interface{}{{"note": "hello", "count": float64(3)}}
+	if !reflect.DeepEqual(records, expected) {
+		t.Errorf("ConvertCSVToJSON() = %s, want the first sheet", result)
+	}
+}
+
+func TestReadXLSXCellLimits(t *testing.T) {
+	sheet := func(rows, merges string) []byte {
+		parts := make(map[string]string, len(testWorkbookParts))
+		for name, content := range testWorkbookParts {
+			parts[name] = content
+		}
+		parts["xl/worksheets/sheet1.xml"] = `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <sheetData>` + rows + `</sheetData>` + merges + `
+</worksheet>`
+		return buildXLSX(t, parts)
+	}
+
+	for _, ref := range []string{"ZZZZZZZZZZZZZZ1", "XFE1", "A1048577", "A0"} {
+		data := sheet(`<row r="1"><c r="`+ref+`" t="inlineStr"><is><t>x</t></is></c></row>`, "")
+		if _, err := readXLSX(bytes.NewReader(data), "1"); err == nil {
+			t.Errorf("readXLSX() accepted the out-of-range cell %s", ref)
+		}
+	}
+
+	// An oversized merge range is ignored rather than padding rows to its width
+	data := sheet(`<row r="1"><c r="A1" t="inlineStr"><is><t>x</t></is></c></row>`,
+		`<mergeCells><mergeCell ref="A1:ZZZZZZZ1"/><mergeCell ref="A1:B9999999"/></mergeCells>`)
+	records, err := readXLSX(bytes.NewReader(data), "1")
+	if err != nil {
+		t.Fatalf("readXLSX() error = %v", err)
+	}
+	if expected := [][]string{{"x"}}; !reflect.DeepEqual(records, expected) {
+		t.Errorf("readXLSX() = %q, want %q", records, expected)
+	}
+
+	// The last cell Excel allows is still read
+	data = sheet(`<row r="1"><c r="XFD1" t="inlineStr"><is><t>x</t></is></c></row>`, "")
+	records, err = readXLSX(bytes.NewReader(data), "1")
+	if err != nil {
+		t.Fatalf("readXLSX() on XFD1 error = %v", err)
+	}
+	if len(records[0]) != 16384 {
+		t.Errorf("readXLSX() on XFD1 = %d cells, want 16384", len(records[0]))
+	}
+}
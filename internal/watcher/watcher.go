@@ -0,0 +1,129 @@
+package watcher
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// fileState is what the watcher last observed for a file
+type fileState struct {
+	size        int64
+	modTime     time.Time
+	stableSince time.Time
+}
+
+// Watcher polls a directory tree for new or changed files and reports them once
+// their size and modification time have stopped changing for StableFor. It
+// uses plain polling so it works on every platform without inotify.
+type Watcher struct {
+	Dir       string
+	Match     func(path string) bool
+	Interval  time.Duration
+	StableFor time.Duration
+
+	seen      map[string]fileState
+	processed map[string]fileState
+}
+
+// New returns a Watcher for dir that reports files accepted by match
+func New(dir string, match func(path string) bool, interval, stableFor time.Duration) *Watcher {
+	return &Watcher{
+		Dir:       dir,
+		Match:     match,
+		Interval:  interval,
+		StableFor: stableFor,
+		seen:      make(map[string]fileState),
+		processed: make(map[string]fileState),
+	}
+}
+
+// Poll scans the directory once and returns the files that are ready: stable
+// for at least StableFor as of now and not yet reported in their current state
+func (w *Watcher) Poll(now time.Time) ([]string, error) {
+	current := make(map[string]bool)
+	var ready []string
+
+	err := filepath.WalkDir(w.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Files can disappear between listing and stat; skip them
+			if path != w.Dir {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || (w.Match != nil && !w.Match(path)) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+
+		current[path] = true
+		state, ok := w.seen[path]
+		if !ok || state.size != info.Size() || !state.modTime.Equal(info.ModTime()) {
+			state = fileState{size: info.Size(), modTime: info.ModTime(), stableSince: now}
+			w.seen[path] = state
+		}
+
+		if done, ok := w.processed[path]; ok && done.size == state.size && done.modTime.Equal(state.modTime) {
+			return nil
+		}
+		if now.Sub(state.stableSince) >= w.StableFor {
+			ready = append(ready, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Forget files that were removed or moved away
+	for path := range w.seen {
+		if !current[path] {
+			delete(w.seen, path)
+			delete(w.processed, path)
+		}
+	}
+
+	sort.Strings(ready)
+	return ready, nil
+}
+
+// MarkProcessed records that path was handled in its current state, so it is
+// only reported again after it changes
+func (w *Watcher) MarkProcessed(path string) {
+	if state, ok := w.seen[path]; ok {
+		w.processed[path] = state
+	}
+}
+
+// Run polls every Interval until ctx is cancelled, calling handle for each
+// ready file. Files are marked processed whether or not handle succeeds so a
+// failing file is retried only after it changes.
+func (w *Watcher) Run(ctx context.Context, handle func(path string) error, onError func(error)) error {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	for {
+		ready, err := w.Poll(time.Now())
+		if err != nil {
+			onError(err)
+		}
+		for _, path := range ready {
+			if err := handle(path); err != nil {
+				onError(err)
+			}
+			w.MarkProcessed(path)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
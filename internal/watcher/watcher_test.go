@@ -0,0 +1,54 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPollWaitsForStableFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data.csv")
+	if err := os.WriteFile(path, []byte("a,b\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("x"), 0644)
+
+	w := New(dir, func(p string) bool { return strings.HasSuffix(p, ".csv") }, time.Second, 5*time.Second)
+	start := time.Now()
+
+	poll := func(at time.Time) []string {
+		t.Helper()
+		ready, err := w.Poll(at)
+		if err != nil {
+			t.Fatalf("Poll() error = %v", err)
+		}
+		return ready
+	}
+
+	if ready := poll(start); len(ready) != 0 {
+		t.Errorf("Poll() = %v before the file is stable", ready)
+	}
+	if ready := poll(start.Add(5 * time.Second)); !reflect.DeepEqual(ready, []string{path}) {
+		t.Errorf("Poll() = %v, want [%s]", ready, path)
+	}
+
+	w.MarkProcessed(path)
+	if ready := poll(start.Add(10 * time.Second)); len(ready) != 0 {
+		t.Errorf("Poll() = %v after the file was processed", ready)
+	}
+
+	// A change restarts the stability timer
+	if err := os.WriteFile(path, []byte("a,b\n1,2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if ready := poll(start.Add(11 * time.Second)); len(ready) != 0 {
+		t.Errorf("Poll() = %v right after the file changed", ready)
+	}
+	if ready := poll(start.Add(16 * time.Second)); !reflect.DeepEqual(ready, []string{path}) {
+		t.Errorf("Poll() = %v, want the changed file", ready)
+	}
+}